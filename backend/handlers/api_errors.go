@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"restaurant-app/backend/i18n"
+)
+
+// Codes d'erreur de l'API. Ils sont stables: les applications s'appuient dessus
+// plutôt que sur le texte du message, qui dépend de la langue.
+const (
+	// Requête et authentification
+	ErrMethodNotAllowed     = "METHOD_NOT_ALLOWED"
+	ErrInvalidJSON          = "INVALID_JSON"
+	ErrInvalidURL           = "INVALID_URL"
+	ErrFormInvalid          = "FORM_INVALID"
+	ErrRouteNotFound        = "ROUTE_NOT_FOUND"
+	ErrValidationFailed     = "VALIDATION_FAILED"
+	ErrAuthRequired         = "AUTH_REQUIRED"
+	ErrAuthInvalid          = "AUTH_INVALID"
+	ErrSessionExpired       = "SESSION_EXPIRED"
+	ErrAdminRequired        = "ADMIN_REQUIRED"
+	ErrPermissionDenied     = "PERMISSION_DENIED"
+	ErrInvalidCredentials   = "INVALID_CREDENTIALS"
+	ErrTooManyAttempts      = "TOO_MANY_LOGIN_ATTEMPTS"
+	ErrLockoutNotFound      = "LOCKOUT_NOT_FOUND"
+	ErrTwoFactorCodeInvalid = "TWO_FACTOR_CODE_INVALID"
+	ErrTwoFactorChallenge   = "TWO_FACTOR_CHALLENGE_INVALID"
+	ErrTwoFactorEnabled     = "TWO_FACTOR_ALREADY_ENABLED"
+	ErrTwoFactorNotEnabled  = "TWO_FACTOR_NOT_ENABLED"
+	ErrTwoFactorNotSetUp    = "TWO_FACTOR_SETUP_MISSING"
+	ErrTwoFactorRequired    = "TWO_FACTOR_SETUP_REQUIRED"
+	ErrTwoFactorMandatory   = "TWO_FACTOR_MANDATORY"
+	ErrServerMisconfigured  = "SERVER_MISCONFIGURED"
+	ErrInternal             = "INTERNAL_ERROR"
+	ErrStreamingUnsupported = "STREAMING_UNSUPPORTED"
+
+	// Clients
+	ErrClientNotFound           = "CLIENT_NOT_FOUND"
+	ErrEmailTaken               = "EMAIL_TAKEN"
+	ErrEmailInvalid             = "EMAIL_INVALID"
+	ErrLanguageUnsupported      = "LANGUAGE_UNSUPPORTED"
+	ErrResetTokenInvalid        = "RESET_TOKEN_INVALID"
+	ErrEmailNotVerified         = "EMAIL_NOT_VERIFIED"
+	ErrEmailAlreadyVerified     = "EMAIL_ALREADY_VERIFIED"
+	ErrVerificationInvalid      = "VERIFICATION_TOKEN_INVALID"
+	ErrCurrentPasswordIncorrect = "CURRENT_PASSWORD_INCORRECT"
+	ErrStaffAccountNotDeletable = "STAFF_ACCOUNT_NOT_DELETABLE"
+	ErrClientErased             = "CLIENT_ERASED"
+
+	// Personnel
+	ErrRoleInvalid        = "ROLE_INVALID"
+	ErrLastOwner          = "LAST_OWNER"
+	ErrInvitationNotFound = "INVITATION_NOT_FOUND"
+	ErrInvitationInvalid  = "INVITATION_INVALID"
+	ErrInvitationAccepted = "INVITATION_ALREADY_ACCEPTED"
+
+	// Réservations
+	ErrReservationNotFound       = "RESERVATION_NOT_FOUND"
+	ErrReservationInPast         = "RESERVATION_IN_PAST"
+	ErrReservationNotCancellable = "RESERVATION_NOT_CANCELLABLE"
+	ErrReservationStatusInvalid  = "RESERVATION_STATUS_INVALID"
+	ErrReservationNeedsVerified  = "RESERVATION_REQUIRES_VERIFIED_ACCOUNT"
+	ErrDateFormatInvalid         = "DATE_FORMAT_INVALID"
+
+	// Plats et panier
+	ErrDishNotFound     = "DISH_NOT_FOUND"
+	ErrDishImageInvalid = "DISH_IMAGE_INVALID"
+	ErrCartItemNotFound = "CART_ITEM_NOT_FOUND"
+	ErrCartEmpty        = "CART_EMPTY"
+
+	// Commandes et cuisine
+	ErrOrderNotFound             = "ORDER_NOT_FOUND"
+	ErrOrderStatusInvalid        = "ORDER_STATUS_INVALID"
+	ErrOrderTransitionNotAllowed = "ORDER_TRANSITION_NOT_ALLOWED"
+	ErrOrderStatusFinal          = "ORDER_STATUS_FINAL"
+	ErrOrderNotInKitchen         = "ORDER_NOT_IN_KITCHEN"
+	ErrTicketNotFound            = "TICKET_NOT_FOUND"
+
+	// Paiements
+	ErrOrderAlreadyPaid        = "ORDER_ALREADY_PAID"
+	ErrOrderCancelled          = "ORDER_CANCELLED"
+	ErrPaymentNotFound         = "PAYMENT_NOT_FOUND"
+	ErrPaymentMethodInvalid    = "PAYMENT_METHOD_INVALID"
+	ErrPaymentGateway          = "PAYMENT_GATEWAY_ERROR"
+	ErrPaymentNotCollectable   = "PAYMENT_NOT_COLLECTABLE"
+	ErrPaymentNotRefundable    = "PAYMENT_NOT_REFUNDABLE"
+	ErrPaymentExceedsBalance   = "PAYMENT_EXCEEDS_BALANCE"
+	ErrRefundAmountInvalid     = "REFUND_AMOUNT_INVALID"
+	ErrRefundAmountExceeded    = "REFUND_AMOUNT_EXCEEDED"
+	ErrWebhookInvalid          = "WEBHOOK_INVALID"
+	ErrWebhookSignatureInvalid = "WEBHOOK_SIGNATURE_INVALID"
+
+	// Notifications et modèles de messages
+	ErrNotificationNotFound = "NOTIFICATION_NOT_FOUND"
+	ErrTemplateNotFound     = "TEMPLATE_NOT_FOUND"
+	ErrTemplateInvalid      = "TEMPLATE_INVALID"
+)
+
+// Codes des erreurs de champ (détails d'une erreur de validation)
+const (
+	FieldRequired       = "REQUIRED"
+	FieldInvalid        = "INVALID"
+	FieldMustBePositive = "MUST_BE_POSITIVE"
+	FieldInPast         = "IN_PAST"
+	FieldReadOnly       = "READ_ONLY"
+)
+
+// errorMessages contient le message de chaque code, par langue. Les {params} sont remplacés
+// par les valeurs transmises avec l'erreur.
+var errorMessages = map[string]map[string]string{
+	ErrMethodNotAllowed:     {i18n.FR: "Méthode non autorisée.", i18n.EN: "Method not allowed."},
+	ErrInvalidJSON:          {i18n.FR: "Requête invalide: format JSON incorrect.", i18n.EN: "Invalid request: malformed JSON."},
+	ErrInvalidURL:           {i18n.FR: "URL invalide ou identifiant manquant.", i18n.EN: "Invalid URL or missing identifier."},
+	ErrFormInvalid:          {i18n.FR: "Formulaire invalide (multipart attendu, 10 Mo maximum).", i18n.EN: "Invalid form (multipart expected, 10 MB maximum)."},
+	ErrRouteNotFound:        {i18n.FR: "Route inconnue.", i18n.EN: "Unknown route."},
+	ErrValidationFailed:     {i18n.FR: "Certains champs sont manquants ou invalides.", i18n.EN: "Some fields are missing or invalid."},
+	ErrAuthRequired:         {i18n.FR: "Authentification requise.", i18n.EN: "Authentication required."},
+	ErrAuthInvalid:          {i18n.FR: "Authentification invalide.", i18n.EN: "Invalid authentication."},
+	ErrSessionExpired:       {i18n.FR: "Session expirée. Rafraîchissez le jeton d'accès.", i18n.EN: "Session expired. Refresh the access token."},
+	ErrAdminRequired:        {i18n.FR: "Accès réservé au personnel.", i18n.EN: "Staff access required."},
+	ErrPermissionDenied:     {i18n.FR: "Le rôle '{role}' ne permet pas cette action (droit requis: {permission}).", i18n.EN: "The '{role}' role does not allow this action (required permission: {permission})."},
+	ErrInvalidCredentials:   {i18n.FR: "Identifiants incorrects.", i18n.EN: "Incorrect email or password."},
+	ErrTooManyAttempts:      {i18n.FR: "Trop de tentatives de connexion. Réessayez dans {seconds} secondes.", i18n.EN: "Too many sign-in attempts. Try again in {seconds} seconds."},
+	ErrLockoutNotFound:      {i18n.FR: "Verrouillage non trouvé.", i18n.EN: "Lockout not found."},
+	ErrTwoFactorCodeInvalid: {i18n.FR: "Code de vérification incorrect.", i18n.EN: "Incorrect verification code."},
+	ErrTwoFactorChallenge:   {i18n.FR: "Connexion expirée ou déjà terminée. Saisissez à nouveau votre mot de passe.", i18n.EN: "Sign-in expired or already completed. Enter your password again."},
+	ErrTwoFactorEnabled:     {i18n.FR: "La double authentification est déjà activée.", i18n.EN: "Two-factor authentication is already enabled."},
+	ErrTwoFactorNotEnabled:  {i18n.FR: "La double authentification n'est pas activée.", i18n.EN: "Two-factor authentication is not enabled."},
+	ErrTwoFactorNotSetUp:    {i18n.FR: "Aucune double authentification en cours de configuration.", i18n.EN: "No two-factor authentication setup in progress."},
+	ErrTwoFactorRequired:    {i18n.FR: "Activez la double authentification pour accéder à l'administration.", i18n.EN: "Enable two-factor authentication to access the admin area."},
+	ErrTwoFactorMandatory:   {i18n.FR: "La double authentification est obligatoire pour le personnel.", i18n.EN: "Two-factor authentication is mandatory for staff accounts."},
+	ErrServerMisconfigured:  {i18n.FR: "Erreur de configuration du serveur. Veuillez contacter l'administrateur.", i18n.EN: "Server configuration error. Please contact the administrator."},
+	ErrInternal:             {i18n.FR: "Erreur interne du serveur. Veuillez réessayer.", i18n.EN: "Internal server error. Please try again."},
+	ErrStreamingUnsupported: {i18n.FR: "Streaming non supporté par le serveur.", i18n.EN: "Streaming is not supported by the server."},
+
+	ErrClientNotFound:           {i18n.FR: "Client non trouvé.", i18n.EN: "Customer not found."},
+	ErrEmailTaken:               {i18n.FR: "Un compte existe déjà avec cet email.", i18n.EN: "An account already exists with this email."},
+	ErrEmailInvalid:             {i18n.FR: "Email invalide.", i18n.EN: "Invalid email address."},
+	ErrLanguageUnsupported:      {i18n.FR: "Langue non prise en charge (fr ou en).", i18n.EN: "Unsupported language (fr or en)."},
+	ErrResetTokenInvalid:        {i18n.FR: "Lien de réinitialisation invalide, déjà utilisé ou expiré.", i18n.EN: "Invalid, already used or expired reset link."},
+	ErrEmailNotVerified:         {i18n.FR: "Adresse email non confirmée. Ouvrez le lien reçu par email ou demandez un nouvel envoi.", i18n.EN: "Email address not confirmed. Open the link we emailed you or ask for a new one."},
+	ErrEmailAlreadyVerified:     {i18n.FR: "Adresse email déjà confirmée.", i18n.EN: "Email address already confirmed."},
+	ErrVerificationInvalid:      {i18n.FR: "Lien de confirmation invalide, déjà utilisé ou expiré.", i18n.EN: "Invalid, already used or expired confirmation link."},
+	ErrCurrentPasswordIncorrect: {i18n.FR: "Mot de passe actuel incorrect.", i18n.EN: "Current password is incorrect."},
+	ErrStaffAccountNotDeletable: {i18n.FR: "Un compte du personnel ne peut pas être supprimé: son rôle doit d'abord être retiré par un propriétaire.", i18n.EN: "A staff account cannot be deleted: an owner must remove its role first."},
+	ErrClientErased:             {i18n.FR: "Les données de ce client ont été effacées.", i18n.EN: "This customer's data has been erased."},
+
+	ErrRoleInvalid:        {i18n.FR: "Rôle inconnu (owner, manager, waiter, chef ou cashier).", i18n.EN: "Unknown role (owner, manager, waiter, chef or cashier)."},
+	ErrLastOwner:          {i18n.FR: "Le restaurant doit garder au moins un propriétaire.", i18n.EN: "The restaurant must keep at least one owner."},
+	ErrInvitationNotFound: {i18n.FR: "Invitation non trouvée.", i18n.EN: "Invitation not found."},
+	ErrInvitationInvalid:  {i18n.FR: "Invitation invalide, déjà utilisée ou expirée.", i18n.EN: "Invalid, already used or expired invitation."},
+	ErrInvitationAccepted: {i18n.FR: "Cette invitation a déjà été acceptée.", i18n.EN: "This invitation has already been accepted."},
+
+	ErrReservationNotFound:       {i18n.FR: "Réservation non trouvée.", i18n.EN: "Reservation not found."},
+	ErrReservationInPast:         {i18n.FR: "La date et l'heure de réservation ne peuvent pas être dans le passé.", i18n.EN: "The reservation date and time cannot be in the past."},
+	ErrReservationNotCancellable: {i18n.FR: "Cette réservation ne peut pas être annulée dans son état actuel.", i18n.EN: "This reservation can no longer be cancelled."},
+	ErrReservationStatusInvalid:  {i18n.FR: "Statut de réservation invalide.", i18n.EN: "Invalid reservation status."},
+	ErrReservationNeedsVerified:  {i18n.FR: "Connectez-vous avec une adresse email confirmée pour réserver pour {min} personnes ou plus.", i18n.EN: "Sign in with a confirmed email address to book for {min} guests or more."},
+	ErrDateFormatInvalid:         {i18n.FR: "Format de date invalide. Attendu ISO 8601 (ex: 2006-01-02T15:04:05Z).", i18n.EN: "Invalid date format. Expected ISO 8601 (e.g. 2006-01-02T15:04:05Z)."},
+
+	ErrDishNotFound:     {i18n.FR: "Plat non trouvé.", i18n.EN: "Dish not found."},
+	ErrDishImageInvalid: {i18n.FR: "Image du plat invalide ou illisible.", i18n.EN: "The dish image is invalid or unreadable."},
+	ErrCartItemNotFound: {i18n.FR: "Ce plat n'est pas dans le panier.", i18n.EN: "This dish is not in the cart."},
+	ErrCartEmpty:        {i18n.FR: "Le panier est vide.", i18n.EN: "The cart is empty."},
+
+	ErrOrderNotFound:             {i18n.FR: "Commande non trouvée.", i18n.EN: "Order not found."},
+	ErrOrderStatusInvalid:        {i18n.FR: "Statut de commande invalide.", i18n.EN: "Invalid order status."},
+	ErrOrderTransitionNotAllowed: {i18n.FR: "Impossible de passer la commande de '{from}' à '{to}'.", i18n.EN: "The order cannot go from '{from}' to '{to}'."},
+	ErrOrderStatusFinal:          {i18n.FR: "La commande est déjà au statut final '{from}'.", i18n.EN: "The order is already in its final status '{from}'."},
+	ErrOrderNotInKitchen:         {i18n.FR: "Cette commande n'est pas en cuisine (statut: {from}).", i18n.EN: "This order is not in the kitchen (status: {from})."},
+	ErrTicketNotFound:            {i18n.FR: "Ticket ou ligne non trouvé.", i18n.EN: "Ticket or line not found."},
+
+	ErrOrderAlreadyPaid:        {i18n.FR: "Cette commande est déjà payée.", i18n.EN: "This order is already paid."},
+	ErrOrderCancelled:          {i18n.FR: "Une commande annulée ne peut pas être payée.", i18n.EN: "A cancelled order cannot be paid."},
+	ErrPaymentNotFound:         {i18n.FR: "Paiement non trouvé.", i18n.EN: "Payment not found."},
+	ErrPaymentMethodInvalid:    {i18n.FR: "Méthode de paiement invalide.", i18n.EN: "Invalid payment method."},
+	ErrPaymentGateway:          {i18n.FR: "Échec de l'initiation du paiement.", i18n.EN: "The payment could not be started."},
+	ErrPaymentNotCollectable:   {i18n.FR: "Seul un paiement en espèces ou au comptoir en attente peut être encaissé.", i18n.EN: "Only a pending cash or counter payment can be collected."},
+	ErrPaymentNotRefundable:    {i18n.FR: "Seul un paiement encaissé et non entièrement remboursé peut être remboursé.", i18n.EN: "Only a collected, not fully refunded payment can be refunded."},
+	ErrPaymentExceedsBalance:   {i18n.FR: "Ce paiement dépasse le reste à payer de la commande.", i18n.EN: "This payment exceeds the amount left to pay on the order."},
+	ErrRefundAmountInvalid:     {i18n.FR: "Le montant du remboursement doit être positif.", i18n.EN: "The refund amount must be positive."},
+	ErrRefundAmountExceeded:    {i18n.FR: "Le montant dépasse ce qui reste remboursable sur ce paiement.", i18n.EN: "The amount exceeds what is left to refund on this payment."},
+	ErrWebhookInvalid:          {i18n.FR: "Webhook de paiement invalide.", i18n.EN: "Invalid payment webhook."},
+	ErrWebhookSignatureInvalid: {i18n.FR: "Signature du webhook invalide.", i18n.EN: "Invalid webhook signature."},
+
+	ErrNotificationNotFound: {i18n.FR: "Notification non trouvée.", i18n.EN: "Notification not found."},
+	ErrTemplateNotFound:     {i18n.FR: "Modèle de message non trouvé.", i18n.EN: "Message template not found."},
+	ErrTemplateInvalid:      {i18n.FR: "Modèle invalide: {error}", i18n.EN: "Invalid template: {error}"},
+
+	FieldRequired:       {i18n.FR: "Ce champ est requis.", i18n.EN: "This field is required."},
+	FieldInvalid:        {i18n.FR: "Valeur invalide.", i18n.EN: "Invalid value."},
+	FieldMustBePositive: {i18n.FR: "Doit être supérieur à zéro.", i18n.EN: "Must be greater than zero."},
+	FieldInPast:         {i18n.FR: "Ne peut pas être dans le passé.", i18n.EN: "Cannot be in the past."},
+	FieldReadOnly:       {i18n.FR: "Ce champ ne peut pas être modifié ici.", i18n.EN: "This field cannot be changed here."},
+}
+
+// FieldError est le détail d'une erreur de validation sur un champ de la requête.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// APIError est l'enveloppe JSON de toutes les réponses d'erreur de l'API.
+type APIError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Params  map[string]string `json:"params,omitempty"`
+	Details []FieldError      `json:"details,omitempty"`
+}
+
+// Field construit le détail d'une erreur sur un champ (le message est ajouté à l'envoi).
+func Field(field, code string) FieldError {
+	return FieldError{Field: field, Code: code}
+}
+
+// errorMessage renvoie le message d'un code dans la langue donnée, paramètres remplacés.
+func errorMessage(code, lang string, params map[string]string) string {
+	messages, ok := errorMessages[code]
+	if !ok {
+		return code
+	}
+	message, ok := messages[lang]
+	if !ok {
+		message = messages[i18n.DefaultLang]
+	}
+	for name, value := range params {
+		message = strings.ReplaceAll(message, "{"+name+"}", value)
+	}
+	return message
+}
+
+// requestLang renvoie la langue des messages d'erreur, d'après l'en-tête Accept-Language.
+func requestLang(r *http.Request) string {
+	if r == nil {
+		return i18n.DefaultLang
+	}
+	return i18n.Resolve(i18n.FromAcceptLanguage(r.Header.Get("Accept-Language")))
+}
+
+// writeError envoie l'erreur dans l'enveloppe JSON commune, avec des messages dans la langue de la requête.
+func writeError(w http.ResponseWriter, r *http.Request, status int, apiErr APIError) {
+	lang := requestLang(r)
+	apiErr.Message = errorMessage(apiErr.Code, lang, apiErr.Params)
+	for i := range apiErr.Details {
+		apiErr.Details[i].Message = errorMessage(apiErr.Details[i].Code, lang, nil)
+	}
+	respondWithJSON(w, status, apiErr)
+}
+
+// respondWithError envoie une erreur de code donné, avec d'éventuels détails par champ.
+func respondWithError(w http.ResponseWriter, r *http.Request, status int, code string, details ...FieldError) {
+	writeError(w, r, status, APIError{Code: code, Details: details})
+}
+
+// respondWithErrorParams envoie une erreur dont le message contient des valeurs (ex: statuts).
+func respondWithErrorParams(w http.ResponseWriter, r *http.Request, status int, code string, params map[string]string) {
+	writeError(w, r, status, APIError{Code: code, Params: params})
+}
+
+// RespondWithError envoie une erreur dans l'enveloppe JSON commune (pour les routes hors du package).
+func RespondWithError(w http.ResponseWriter, r *http.Request, status int, code string, details ...FieldError) {
+	respondWithError(w, r, status, code, details...)
+}
+
+// RespondWithErrorParams envoie une erreur avec des valeurs dans le message (pour les routes hors du package).
+func RespondWithErrorParams(w http.ResponseWriter, r *http.Request, status int, code string, params map[string]string) {
+	respondWithErrorParams(w, r, status, code, params)
+}
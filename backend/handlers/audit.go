@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Actions enregistrées dans le journal d'audit
+const (
+	AuditCreate         = "create"
+	AuditUpdate         = "update"
+	AuditDelete         = "delete"
+	AuditErase          = "erase"          // Effacement des données personnelles d'un client
+	AuditRoleChange     = "role_change"    // Attribution ou retrait d'un rôle du personnel
+	AuditPasswordReset  = "password_reset" // Nouveau mot de passe fixé par un admin
+	AuditStatusChange   = "status_change"  // Changement de statut d'une commande
+	AuditCollect        = "collect"        // Encaissement d'un paiement en espèces ou au comptoir
+	AuditRefund         = "refund"         // Remboursement de tout ou partie d'un paiement
+	AuditRevoke         = "revoke"         // Révocation d'une invitation du personnel
+	AuditTwoFactorReset = "2fa_reset"      // Double authentification désactivée par un admin
+)
+
+// Types d'objets suivis par le journal d'audit
+const (
+	AuditReservation = "reservation"
+	AuditClient      = "client"
+	AuditPlat        = "plat"
+	AuditCommande    = "commande"
+	AuditPaiement    = "paiement"
+	AuditModele      = "modele_message" // ID: "{clé}/{langue}"
+	AuditInvitation  = "invitation"
+	AuditLockout     = "lockout" // Compteur d'échecs de connexion
+)
+
+// auditMasked remplace la valeur des champs sensibles: le journal indique qu'ils ont changé, sans les exposer.
+const auditMasked = "[masqué]"
+
+// auditSensitiveFields liste les champs dont la valeur n'est jamais écrite dans le journal.
+var auditSensitiveFields = map[string]bool{"motDePasse": true}
+
+// auditRedacted remplace les données personnelles d'un client effacé dans les entrées déjà écrites.
+const auditRedacted = "[effacé]"
+
+// auditPersonalFields liste, par type d'objet, les champs effacés du journal avec les données du client (voir RedactAudit).
+var auditPersonalFields = map[string][]string{
+	AuditClient:      {"email", "nomClient", "prenomClient", "numTel", "adresse"},
+	AuditReservation: {"client_name", "client_email", "client_phone", "special_notes", "event_description"},
+	AuditLockout:     {"value"}, // Entrées écrites avant lockoutAuditView, avec l'email du compteur
+}
+
+// auditIgnoredFields liste les champs mis à jour automatiquement, sans intérêt dans un diff.
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// AuditChange est l'ancienne et la nouvelle valeur d'un champ modifié.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// auditSnapshot convertit un objet en map (clés JSON), vide pour nil.
+func auditSnapshot(v interface{}) (map[string]interface{}, error) {
+	snapshot := map[string]interface{}{}
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return snapshot, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, json.Unmarshal(data, &snapshot)
+}
+
+// AuditDiff compare deux états d'un objet et renvoie les champs modifiés.
+// before vaut nil pour une création, after vaut nil pour une suppression.
+func AuditDiff(before, after interface{}) (map[string]AuditChange, error) {
+	b, err := auditSnapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := auditSnapshot(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]AuditChange{}
+	for _, snapshot := range []map[string]interface{}{b, a} {
+		for field := range snapshot {
+			if _, done := changes[field]; done || auditIgnoredFields[field] || reflect.DeepEqual(b[field], a[field]) {
+				continue
+			}
+			change := AuditChange{Before: b[field], After: a[field]}
+			if auditSensitiveFields[field] {
+				change = AuditChange{Before: auditMasked, After: auditMasked}
+			}
+			changes[field] = change
+		}
+	}
+	return changes, nil
+}
+
+// RecordAudit enregistre une modification faite par le personnel: l'auteur et l'adresse IP sont lus
+// dans la requête, les champs modifiés sont calculés à partir des deux états de l'objet.
+// Une mise à jour sans changement n'est pas enregistrée.
+func RecordAudit(db *gorm.DB, r *http.Request, action, entityType, entityID string, before, after interface{}) error {
+	changes, err := AuditDiff(before, after)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 && action == AuditUpdate {
+		return nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	actor := ActorFromContext(r.Context())
+	return db.Create(&models.JournalAudit{
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		ActorRole:  actor.Role,
+		IP:         clientIP(r),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    data,
+	}).Error
+}
+
+// auditRedact efface une valeur du journal; une valeur absente ou vide reste telle quelle.
+func auditRedact(v interface{}) interface{} {
+	if v == nil || v == "" {
+		return v
+	}
+	return auditRedacted
+}
+
+// RedactAudit efface des entrées données les valeurs des champs personnels de leur type d'objet
+// (voir auditPersonalFields). C'est la seule modification permise du journal, faite par EraseClient.
+func RedactAudit(db *gorm.DB, entries []models.JournalAudit) error {
+	for _, entry := range entries {
+		var changes map[string]AuditChange
+		if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+			return err
+		}
+		redacted := false
+		for _, field := range auditPersonalFields[entry.EntityType] {
+			if change, ok := changes[field]; ok {
+				changes[field] = AuditChange{Before: auditRedact(change.Before), After: auditRedact(change.After)}
+				redacted = true
+			}
+		}
+		if !redacted {
+			continue
+		}
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&entry).UpdateColumn("changes", data).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientAuditView renvoie l'état d'un compte tel qu'il est comparé dans le journal d'audit.
+// L'empreinte du mot de passe y figure pour qu'une réinitialisation apparaisse (masquée) dans le diff.
+func ClientAuditView(c models.Client) map[string]interface{} {
+	view := Profile(c)
+	view["motDePasse"] = c.MotDePasseHashed
+	return view
+}
+
+// Nombre d'entrées renvoyées par /admin/audit
+const (
+	auditPageSize    = 100
+	auditMaxPageSize = 500
+)
+
+// AuditHandler gère la consultation du journal d'audit.
+type AuditHandler struct {
+	DB *gorm.DB
+}
+
+// NewAuditHandler crée une nouvelle instance de AuditHandler.
+func NewAuditHandler(db *gorm.DB) *AuditHandler {
+	return &AuditHandler{DB: db}
+}
+
+// AuditPage est une page du journal d'audit.
+type AuditPage struct {
+	Entries  []models.JournalAudit `json:"entries"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"page_size"`
+	Total    int64                 `json:"total"`
+}
+
+// ListHandler renvoie le journal d'audit, les entrées les plus récentes en premier.
+// Filtres optionnels: actor_id, action, entity_type, entity_id, from et to (RFC 3339).
+// Méthode: GET /admin/audit?entity_type=reservation&entity_id={id}&page=1&page_size=100
+func (ah *AuditHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	page := AuditPage{
+		Page:     queryInt(r, "page", 1),
+		PageSize: queryInt(r, "page_size", auditPageSize),
+	}
+	if page.PageSize > auditMaxPageSize {
+		page.PageSize = auditMaxPageSize
+	}
+
+	q := r.URL.Query()
+	query := ah.DB.Model(&models.JournalAudit{})
+	for _, filter := range []string{"actor_id", "action", "entity_type", "entity_id"} {
+		if v := q.Get(filter); v != "" {
+			query = query.Where(filter+" = ?", v)
+		}
+	}
+	for _, bound := range []struct {
+		param, cond string
+	}{{"from", "created_at >= ?"}, {"to", "created_at <= ?"}} {
+		v := q.Get(bound.param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrDateFormatInvalid, Field(bound.param, FieldInvalid))
+			return
+		}
+		query = query.Where(bound.cond, t)
+	}
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		log.Printf("Erreur DB lors du comptage du journal d'audit: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	if err := query.Order("created_at DESC").
+		Limit(page.PageSize).Offset((page.Page - 1) * page.PageSize).
+		Find(&page.Entries).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération du journal d'audit: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
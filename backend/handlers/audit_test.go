@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"restaurant-app/backend/models"
+)
+
+func TestAuditWrittenWithMutation(t *testing.T) {
+	db := newTestDB(t)
+	dh := NewDishHandler(db, t.TempDir(), "http://api.test")
+	plat := models.Plat{Name: "Tajine", Category: "Plats", Price: 12}
+	if err := db.Create(&plat).Error; err != nil {
+		t.Fatalf("création du plat: %v", err)
+	}
+	owner := seedClient(t, db, "owner@test.fr")
+	owner.Role = models.RoleOwner
+	deleteDish := func() int {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/dishes/"+plat.ID, nil)
+		req = req.WithContext(WithStaff(req.Context(), owner))
+		rec := httptest.NewRecorder()
+		dh.DeleteDishHandler(rec, req)
+		return rec.Code
+	}
+
+	// Sans journal d'audit, la suppression est refusée et annulée
+	if err := db.Migrator().RenameTable(&models.JournalAudit{}, "journal_audits_hors_service"); err != nil {
+		t.Fatalf("renommage de la table d'audit: %v", err)
+	}
+	if code := deleteDish(); code != http.StatusInternalServerError {
+		t.Fatalf("suppression sans journal d'audit: statut %d, attendu %d", code, http.StatusInternalServerError)
+	}
+	var count int64
+	db.Model(&models.Plat{}).Where("id = ?", plat.ID).Count(&count)
+	if count != 1 {
+		t.Fatal("plat supprimé alors que l'entrée d'audit n'a pas pu être écrite")
+	}
+
+	if err := db.Migrator().RenameTable("journal_audits_hors_service", &models.JournalAudit{}); err != nil {
+		t.Fatalf("renommage de la table d'audit: %v", err)
+	}
+	if code := deleteDish(); code != http.StatusNoContent {
+		t.Fatalf("suppression: statut %d, attendu %d", code, http.StatusNoContent)
+	}
+	var entry models.JournalAudit
+	if err := db.Where("entity_type = ? AND entity_id = ?", AuditPlat, plat.ID).First(&entry).Error; err != nil {
+		t.Fatalf("entrée d'audit de la suppression: %v", err)
+	}
+	if entry.Action != AuditDelete || entry.ActorID != owner.ID || entry.ActorRole != models.RoleOwner {
+		t.Errorf("entrée d'audit %+v: attendu une suppression par %s (%s)", entry, owner.ID, models.RoleOwner)
+	}
+}
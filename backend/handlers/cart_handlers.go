@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// CartHandler regroupe les dépendances et les méthodes pour le panier d'un client.
+type CartHandler struct {
+	DB *gorm.DB
+}
+
+// NewCartHandler crée une nouvelle instance de CartHandler.
+func NewCartHandler(db *gorm.DB) *CartHandler {
+	return &CartHandler{DB: db}
+}
+
+// CartLine représente une ligne du panier, avec le prix actuel du plat.
+type CartLine struct {
+	PlatID    string  `json:"plat_id"`
+	Name      string  `json:"name"`
+	Category  string  `json:"category"`
+	ImageURL  string  `json:"imageUrl"`
+	UnitPrice float64 `json:"unit_price"`
+	Quantity  int     `json:"quantity"`
+	LineTotal float64 `json:"line_total"`
+}
+
+// CartView est la réponse renvoyée au client pour son panier.
+type CartView struct {
+	ID          string     `json:"ID"`
+	ClientID    string     `json:"client_id"`
+	Items       []CartLine `json:"items"`
+	ItemCount   int        `json:"item_count"`
+	TotalAmount float64    `json:"total_amount"`
+}
+
+// cartItemRequest est le corps attendu pour ajouter ou modifier une ligne du panier.
+type cartItemRequest struct {
+	PlatID   string `json:"plat_id"`
+	Quantity int    `json:"quantity"`
+}
+
+// roundMontant arrondit un montant au centime.
+func roundMontant(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// getOrCreatePanier renvoie l'unique panier du client, en le créant si nécessaire.
+func getOrCreatePanier(tx *gorm.DB, clientID string) (models.Panier, error) {
+	var panier models.Panier
+	err := tx.Where(models.Panier{ClientID: clientID}).FirstOrCreate(&panier).Error
+	return panier, err
+}
+
+// loadPanierLines charge les lignes du panier et les plats associés (indexés par ID).
+func loadPanierLines(tx *gorm.DB, panierID string) ([]models.PanierPlat, map[string]models.Plat, error) {
+	var lignes []models.PanierPlat
+	if err := tx.Where("panier_id = ?", panierID).Find(&lignes).Error; err != nil {
+		return nil, nil, err
+	}
+
+	plats := make(map[string]models.Plat)
+	if len(lignes) == 0 {
+		return lignes, plats, nil
+	}
+
+	platIDs := make([]string, 0, len(lignes))
+	for _, l := range lignes {
+		platIDs = append(platIDs, l.PlatID)
+	}
+	var found []models.Plat
+	if err := tx.Where("id IN ?", platIDs).Find(&found).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, p := range found {
+		plats[p.ID] = p
+	}
+	return lignes, plats, nil
+}
+
+// buildCartView calcule les totaux du panier à partir du prix actuel des plats.
+// Les lignes dont le plat a été supprimé du menu sont ignorées.
+func buildCartView(tx *gorm.DB, panier models.Panier) (CartView, error) {
+	view := CartView{ID: panier.ID, ClientID: panier.ClientID, Items: []CartLine{}}
+
+	lignes, plats, err := loadPanierLines(tx, panier.ID)
+	if err != nil {
+		return view, err
+	}
+
+	for _, l := range lignes {
+		plat, ok := plats[l.PlatID]
+		if !ok {
+			continue
+		}
+		line := CartLine{
+			PlatID:    plat.ID,
+			Name:      plat.Name,
+			Category:  plat.Category,
+			ImageURL:  plat.ImagePath,
+			UnitPrice: plat.Price,
+			Quantity:  l.Quantity,
+			LineTotal: roundMontant(plat.Price * float64(l.Quantity)),
+		}
+		view.Items = append(view.Items, line)
+		view.ItemCount += l.Quantity
+		view.TotalAmount += line.LineTotal
+	}
+	view.TotalAmount = roundMontant(view.TotalAmount)
+	return view, nil
+}
+
+// respondWithCart recharge le panier du client et l'envoie en réponse.
+func (ch *CartHandler) respondWithCart(w http.ResponseWriter, r *http.Request, status int, clientID string) {
+	panier, err := getOrCreatePanier(ch.DB, clientID)
+	if err != nil {
+		log.Printf("Erreur DB lors de la récupération du panier (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	view, err := buildCartView(ch.DB, panier)
+	if err != nil {
+		log.Printf("Erreur DB lors du calcul du panier (ID: %s): %v", panier.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, status, view)
+}
+
+// cartItemIDFromPath extrait l'ID du plat de l'URL /api/cart/items/{platID}.
+func cartItemIDFromPath(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[4]
+}
+
+// GetCartHandler renvoie le panier du client authentifié avec ses totaux.
+// Méthode: GET /api/cart
+func (ch *CartHandler) GetCartHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+	ch.respondWithCart(w, r, http.StatusOK, clientID)
+}
+
+// AddItemHandler ajoute un plat au panier (ou augmente sa quantité s'il y est déjà).
+// Méthode: POST /api/cart/items
+func (ch *CartHandler) AddItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	var req cartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	var details []FieldError
+	if req.PlatID == "" {
+		details = append(details, Field("plat_id", FieldRequired))
+	}
+	if req.Quantity < 0 {
+		details = append(details, Field("quantity", FieldMustBePositive))
+	}
+	if len(details) > 0 {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, details...)
+		return
+	}
+
+	err := ch.DB.Transaction(func(tx *gorm.DB) error {
+		var plat models.Plat
+		if err := tx.First(&plat, "id = ?", req.PlatID).Error; err != nil {
+			return err
+		}
+		panier, err := getOrCreatePanier(tx, clientID)
+		if err != nil {
+			return err
+		}
+
+		var ligne models.PanierPlat
+		err = tx.Where("panier_id = ? AND plat_id = ?", panier.ID, plat.ID).First(&ligne).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ligne = models.PanierPlat{PanierID: panier.ID, PlatID: plat.ID, Quantity: req.Quantity}
+			return tx.Create(&ligne).Error
+		} else if err != nil {
+			return err
+		}
+		ligne.Quantity += req.Quantity
+		return tx.Save(&ligne).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusNotFound, ErrDishNotFound)
+			return
+		}
+		log.Printf("Erreur DB lors de l'ajout au panier (client: %s, plat: %s): %v", clientID, req.PlatID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	log.Printf("Plat ajouté au panier (client: %s, plat: %s, quantité: +%d)", clientID, req.PlatID, req.Quantity)
+	ch.respondWithCart(w, r, http.StatusOK, clientID)
+}
+
+// UpdateItemHandler fixe la quantité d'une ligne du panier (0 supprime la ligne).
+// Méthode: PUT /api/cart/items/{platID}
+func (ch *CartHandler) UpdateItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	platID := cartItemIDFromPath(r.URL.Path)
+	if platID == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+
+	var req cartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	if req.Quantity < 0 {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("quantity", FieldInvalid))
+		return
+	}
+
+	err := ch.DB.Transaction(func(tx *gorm.DB) error {
+		panier, err := getOrCreatePanier(tx, clientID)
+		if err != nil {
+			return err
+		}
+		var ligne models.PanierPlat
+		if err := tx.Where("panier_id = ? AND plat_id = ?", panier.ID, platID).First(&ligne).Error; err != nil {
+			return err
+		}
+		if req.Quantity == 0 {
+			return tx.Where("panier_id = ? AND plat_id = ?", panier.ID, platID).Delete(&models.PanierPlat{}).Error
+		}
+		ligne.Quantity = req.Quantity
+		return tx.Save(&ligne).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusNotFound, ErrCartItemNotFound)
+			return
+		}
+		log.Printf("Erreur DB lors de la mise à jour du panier (client: %s, plat: %s): %v", clientID, platID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	ch.respondWithCart(w, r, http.StatusOK, clientID)
+}
+
+// RemoveItemHandler retire un plat du panier.
+// Méthode: DELETE /api/cart/items/{platID}
+func (ch *CartHandler) RemoveItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	platID := cartItemIDFromPath(r.URL.Path)
+	if platID == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+
+	panier, err := getOrCreatePanier(ch.DB, clientID)
+	if err != nil {
+		log.Printf("Erreur DB lors de la récupération du panier (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	result := ch.DB.Where("panier_id = ? AND plat_id = ?", panier.ID, platID).Delete(&models.PanierPlat{})
+	if result.Error != nil {
+		log.Printf("Erreur DB lors du retrait du plat du panier (client: %s, plat: %s): %v", clientID, platID, result.Error)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondWithError(w, r, http.StatusNotFound, ErrCartItemNotFound)
+		return
+	}
+
+	ch.respondWithCart(w, r, http.StatusOK, clientID)
+}
+
+// ClearCartHandler vide le panier du client.
+// Méthode: DELETE /api/cart
+func (ch *CartHandler) ClearCartHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	panier, err := getOrCreatePanier(ch.DB, clientID)
+	if err == nil {
+		err = ch.DB.Where("panier_id = ?", panier.ID).Delete(&models.PanierPlat{}).Error
+	}
+	if err != nil {
+		log.Printf("Erreur DB lors du vidage du panier (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	log.Printf("Panier vidé (client: %s)", clientID)
+	ch.respondWithCart(w, r, http.StatusOK, clientID)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+
+	"restaurant-app/backend/models"
+)
+
+// contextKey est le type des clés stockées dans le contexte des requêtes (évite les collisions).
+type contextKey string
+
+const (
+	clientIDKey contextKey = "clientID"
+	actorKey    contextKey = "actor"
+)
+
+// Types d'auteurs possibles pour une action (historique des statuts, etc.)
+const (
+	ActorClient = "client"
+	ActorAdmin  = "admin"
+	ActorSystem = "system"
+)
+
+// Actor identifie l'auteur d'une action (client, membre du personnel ou serveur lui-même).
+type Actor struct {
+	Type string
+	ID   string
+	Role string // Rôle du membre du personnel (vide pour un client ou le serveur)
+}
+
+// WithClientID retourne un contexte portant l'ID du client authentifié.
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	ctx = context.WithValue(ctx, clientIDKey, clientID)
+	return WithActor(ctx, Actor{Type: ActorClient, ID: clientID})
+}
+
+// ClientIDFromContext récupère l'ID du client authentifié placé par le middleware.
+func ClientIDFromContext(ctx context.Context) (string, bool) {
+	clientID, ok := ctx.Value(clientIDKey).(string)
+	return clientID, ok && clientID != ""
+}
+
+// WithActor retourne un contexte portant l'auteur des actions de la requête.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// WithStaff retourne un contexte dont les actions sont attribuées au membre du personnel authentifié.
+func WithStaff(ctx context.Context, staff models.Client) context.Context {
+	return WithActor(ctx, Actor{Type: ActorAdmin, ID: staff.ID, Role: staff.Role})
+}
+
+// ActorFromContext récupère l'auteur de la requête (le serveur lui-même par défaut).
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey).(Actor); ok {
+		return actor
+	}
+	return Actor{Type: ActorSystem}
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"restaurant-app/backend/models" // Assurez-vous que ce chemin est correct
@@ -19,19 +20,17 @@ import (
 
 // DishHandler regroupe les dépendances et les méthodes pour les opérations CRUD sur les plats.
 type DishHandler struct {
-	DB         *gorm.DB
-	AdminToken string
-	UploadDir  string
-	ServerURL  string
+	DB        *gorm.DB
+	UploadDir string
+	ServerURL string
 }
 
 // NewDishHandler crée une nouvelle instance de DishHandler.
-func NewDishHandler(db *gorm.DB, adminToken, uploadDir, serverURL string) *DishHandler {
+func NewDishHandler(db *gorm.DB, uploadDir, serverURL string) *DishHandler {
 	return &DishHandler{
-		DB:         db,
-		AdminToken: adminToken,
-		UploadDir:  uploadDir,
-		ServerURL:  serverURL,
+		DB:        db,
+		UploadDir: uploadDir,
+		ServerURL: serverURL,
 	}
 }
 
@@ -53,24 +52,19 @@ func respondWithJSON(w http.ResponseWriter, status int, payload interface{}) {
 	}
 }
 
-// respondWithError est une fonction utilitaire pour envoyer des réponses d'erreur JSON.
-func respondWithError(w http.ResponseWriter, status int, message string) {
-	respondWithJSON(w, status, map[string]string{"message": message})
-}
-
 // CreateDishHandler crée un nouveau plat (accès admin), y compris l'upload d'images.
 // Méthode: POST /admin/dishes
 func (dh *DishHandler) CreateDishHandler(w http.ResponseWriter, r *http.Request) {
 	// CORS et OPTIONS sont gérés par le middleware externe.
 	if r.Method != http.MethodPost {
-		respondWithError(w, http.StatusMethodNotAllowed, "Méthode non autorisée.")
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
 		return
 	}
 
 	err := r.ParseMultipartForm(10 << 20) // Taille max du fichier: 10 Mo
 	if err != nil {
 		log.Printf("Erreur lors du parsing du formulaire multipart: %v", err)
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Erreur lors du parsing du formulaire: %v", err))
+		respondWithError(w, r, http.StatusBadRequest, ErrFormInvalid)
 		return
 	}
 
@@ -83,15 +77,22 @@ func (dh *DishHandler) CreateDishHandler(w http.ResponseWriter, r *http.Request)
 	log.Printf("Données de plat reçues - Nom: '%s', Catégorie: '%s', Prix: '%s', Description: '%s'",
 		name, category, priceStr, description)
 
-	if name == "" || category == "" || priceStr == "" || description == "" {
-		respondWithError(w, http.StatusBadRequest, "Tous les champs (nom, catégorie, prix, description) sont obligatoires.")
+	var details []FieldError
+	for field, value := range map[string]string{"name": name, "category": category, "price": priceStr, "description": description} {
+		if value == "" {
+			details = append(details, Field(field, FieldRequired))
+		}
+	}
+	if len(details) > 0 {
+		sort.Slice(details, func(i, j int) bool { return details[i].Field < details[j].Field })
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, details...)
 		return
 	}
 
 	price, err := parsePrice(priceStr)
 	if err != nil {
 		log.Printf("Erreur de conversion du prix '%s': %v", priceStr, err)
-		respondWithError(w, http.StatusBadRequest, "Format de prix invalide.")
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("price", FieldInvalid))
 		return
 	}
 
@@ -105,21 +106,21 @@ func (dh *DishHandler) CreateDishHandler(w http.ResponseWriter, r *http.Request)
 		dst, err := os.Create(filePath)
 		if err != nil {
 			log.Printf("Échec de la création du fichier image '%s': %v", filePath, err)
-			respondWithError(w, http.StatusInternalServerError, "Échec de la création du fichier image sur le serveur.")
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 			return
 		}
 		defer dst.Close()
 
 		if _, err := io.Copy(dst, file); err != nil {
 			log.Printf("Échec de la copie du fichier image vers '%s': %v", filePath, err)
-			respondWithError(w, http.StatusInternalServerError, "Échec de la copie du fichier image.")
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 			return
 		}
 		imagePath = fmt.Sprintf("%s/uploads/%s", dh.ServerURL, fileName)
 		log.Printf("Image uploadée et enregistrée: %s", imagePath)
 	} else if err != http.ErrMissingFile {
 		log.Printf("Erreur lors de l'upload de l'image (non-missing file): %v", err)
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Erreur lors de l'upload de l'image: %v", err))
+		respondWithError(w, r, http.StatusBadRequest, ErrDishImageInvalid)
 		return
 	}
 
@@ -132,9 +133,16 @@ func (dh *DishHandler) CreateDishHandler(w http.ResponseWriter, r *http.Request)
 		ImagePath:   imagePath,
 	}
 
-	if err := dh.DB.Create(&newPlat).Error; err != nil {
+	// Le plat et son entrée dans le journal d'audit sont créés ensemble
+	err = dh.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newPlat).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, r, AuditCreate, AuditPlat, newPlat.ID, nil, newPlat)
+	})
+	if err != nil {
 		log.Printf("Erreur DB lors de l'ajout du plat: %v", err)
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erreur lors de l'ajout du plat à la base de données: %v", err))
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 		return
 	}
 
@@ -147,14 +155,14 @@ func (dh *DishHandler) CreateDishHandler(w http.ResponseWriter, r *http.Request)
 func (dh *DishHandler) GetDishesHandler(w http.ResponseWriter, r *http.Request) {
 	// CORS et OPTIONS sont gérés par le middleware externe.
 	if r.Method != http.MethodGet {
-		respondWithError(w, http.StatusMethodNotAllowed, "Méthode non autorisée.")
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
 		return
 	}
 
 	var plats []models.Plat
 	if err := dh.DB.Find(&plats).Error; err != nil {
 		log.Printf("Erreur DB lors de la récupération des plats: %v", err)
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Échec de la récupération des plats: %v", err))
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 		return
 	}
 	respondWithJSON(w, http.StatusOK, plats)
@@ -165,13 +173,13 @@ func (dh *DishHandler) GetDishesHandler(w http.ResponseWriter, r *http.Request)
 func (dh *DishHandler) UpdateDishHandler(w http.ResponseWriter, r *http.Request) {
 	// CORS et OPTIONS sont gérés par le middleware externe.
 	if r.Method != http.MethodPut {
-		respondWithError(w, http.StatusMethodNotAllowed, "Méthode non autorisée.")
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
 		return
 	}
 
 	parts := strings.Split(r.URL.Path, "/")
 	if len(parts) < 4 || parts[3] == "" { // Attendu: /admin/dishes/{id}
-		respondWithError(w, http.StatusBadRequest, "ID plat manquant dans l'URL.")
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
 		return
 	}
 	platID := parts[3]
@@ -179,20 +187,21 @@ func (dh *DishHandler) UpdateDishHandler(w http.ResponseWriter, r *http.Request)
 	err := r.ParseMultipartForm(10 << 20) // Taille max du fichier: 10 Mo
 	if err != nil {
 		log.Printf("Erreur lors du parsing du formulaire multipart: %v", err)
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Erreur lors du parsing du formulaire: %v", err))
+		respondWithError(w, r, http.StatusBadRequest, ErrFormInvalid)
 		return
 	}
 
 	var existingPlat models.Plat
 	if err := dh.DB.First(&existingPlat, "ID = ?", platID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			respondWithError(w, http.StatusNotFound, "Plat non trouvé.")
+			respondWithError(w, r, http.StatusNotFound, ErrDishNotFound)
 			return
 		}
 		log.Printf("Erreur DB lors de la récupération du plat (ID: %s): %v", platID, err)
-		respondWithError(w, http.StatusInternalServerError, "Erreur de base de données.")
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 		return
 	}
+	before := existingPlat
 
 	// Met à jour les champs
 	if name := r.FormValue("name"); name != "" {
@@ -205,7 +214,7 @@ func (dh *DishHandler) UpdateDishHandler(w http.ResponseWriter, r *http.Request)
 		if price, err := parsePrice(priceStr); err == nil {
 			existingPlat.Price = price
 		} else {
-			respondWithError(w, http.StatusBadRequest, "Format de prix invalide.")
+			respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("price", FieldInvalid))
 			return
 		}
 	}
@@ -241,25 +250,31 @@ func (dh *DishHandler) UpdateDishHandler(w http.ResponseWriter, r *http.Request)
 		dst, err := os.Create(filePath)
 		if err != nil {
 			log.Printf("Échec de la création du nouveau fichier image '%s': %v", filePath, err)
-			respondWithError(w, http.StatusInternalServerError, "Échec de la création du nouveau fichier image sur le serveur.")
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 			return
 		}
 		defer dst.Close()
 		if _, err := io.Copy(dst, file); err != nil {
 			log.Printf("Échec de la copie du nouveau fichier image vers '%s': %v", filePath, err)
-			respondWithError(w, http.StatusInternalServerError, "Échec de la copie du nouveau fichier image.")
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 			return
 		}
 		existingPlat.ImagePath = fmt.Sprintf("%s/uploads/%s", dh.ServerURL, fileName)
 	} else if err != http.ErrMissingFile { // Si ce n'est pas une erreur "fichier manquant", c'est une autre erreur d'upload
 		log.Printf("Erreur lors de l'upload de la nouvelle image (non-missing file): %v", err)
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Erreur lors de l'upload de la nouvelle image: %v", err))
+		respondWithError(w, r, http.StatusBadRequest, ErrDishImageInvalid)
 		return
 	}
 
-	if err := dh.DB.Save(&existingPlat).Error; err != nil {
+	err = dh.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&existingPlat).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, r, AuditUpdate, AuditPlat, platID, before, existingPlat)
+	})
+	if err != nil {
 		log.Printf("Erreur DB lors de la mise à jour du plat (ID: %s): %v", platID, err)
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Échec de la mise à jour du plat: %v", err))
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 		return
 	}
 
@@ -271,13 +286,13 @@ func (dh *DishHandler) UpdateDishHandler(w http.ResponseWriter, r *http.Request)
 func (dh *DishHandler) DeleteDishHandler(w http.ResponseWriter, r *http.Request) {
 	// CORS et OPTIONS sont gérés par le middleware externe.
 	if r.Method != http.MethodDelete {
-		respondWithError(w, http.StatusMethodNotAllowed, "Méthode non autorisée.")
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
 		return
 	}
 
 	parts := strings.Split(r.URL.Path, "/")
 	if len(parts) < 4 || parts[3] == "" { // Attendu: /admin/dishes/{id}
-		respondWithError(w, http.StatusBadRequest, "ID plat manquant dans l'URL.")
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
 		return
 	}
 	platID := parts[3]
@@ -285,15 +300,33 @@ func (dh *DishHandler) DeleteDishHandler(w http.ResponseWriter, r *http.Request)
 	var plat models.Plat
 	if err := dh.DB.First(&plat, "ID = ?", platID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			respondWithError(w, http.StatusNotFound, "Plat non trouvé.")
+			respondWithError(w, r, http.StatusNotFound, ErrDishNotFound)
 			return
 		}
 		log.Printf("Erreur DB lors de la récupération du plat pour suppression (ID: %s): %v", platID, err)
-		respondWithError(w, http.StatusInternalServerError, "Erreur de base de données.")
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
 		return
 	}
 
-	// Supprime le fichier image associé s'il existe et est géré par le serveur
+	// Les lignes de commande passées gardent leur copie du nom, de la catégorie et du prix (CommandePlat),
+	// seules les lignes de panier en attente sont retirées avec le plat.
+	// La suppression et son entrée dans le journal d'audit sont enregistrées ensemble.
+	err := dh.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("plat_id = ?", platID).Delete(&models.PanierPlat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Plat{}, "ID = ?", platID).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, r, AuditDelete, AuditPlat, platID, plat, nil)
+	})
+	if err != nil {
+		log.Printf("Erreur DB lors de la suppression du plat (ID: %s): %v", platID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	// Supprime le fichier image associé s'il existe et est géré par le serveur (une fois le plat supprimé)
 	if plat.ImagePath != "" && strings.HasPrefix(plat.ImagePath, dh.ServerURL+"/uploads/") {
 		oldFileName := strings.TrimPrefix(plat.ImagePath, dh.ServerURL+"/uploads/")
 		oldFilePath := filepath.Join(dh.UploadDir, oldFileName)
@@ -305,12 +338,6 @@ func (dh *DishHandler) DeleteDishHandler(w http.ResponseWriter, r *http.Request)
 			}
 		}
 	}
-
-	if err := dh.DB.Delete(&models.Plat{}, "ID = ?", platID).Error; err != nil {
-		log.Printf("Erreur DB lors de la suppression du plat (ID: %s): %v", platID, err)
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Échec de la suppression du plat: %v", err))
-		return
-	}
 	w.WriteHeader(http.StatusNoContent)
 	log.Printf("Plat supprimé avec succès: '%s' (ID: %s)", plat.Name, plat.ID)
 }
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Types d'événements poussés aux applications (Server-Sent Events)
+const (
+	EventOrderCreated             = "order.created"
+	EventOrderStatusChanged       = "order.status_changed"
+	EventReservationCreated       = "reservation.created"
+	EventReservationStatusChanged = "reservation.status_changed"
+	EventReservationReminder      = "reservation.reminder"
+)
+
+// sseHeartbeat est l'intervalle des commentaires envoyés pour garder la connexion ouverte.
+const sseHeartbeat = 25 * time.Second
+
+// Event est un événement diffusé aux abonnés.
+// ClientID désigne le client concerné (vide si l'événement ne concerne que l'administration).
+type Event struct {
+	Type     string      `json:"type"`
+	ClientID string      `json:"-"`
+	Data     interface{} `json:"data"`
+	At       time.Time   `json:"at"`
+}
+
+// subscriber est une connexion ouverte sur le flux d'événements.
+type subscriber struct {
+	clientID string // Client abonné (vide pour le flux admin)
+	admin    bool   // Reçoit tous les événements
+	ch       chan Event
+}
+
+// EventBroker diffuse les événements de statut (commandes, réservations) aux connexions ouvertes.
+type EventBroker struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+// NewEventBroker crée un nouveau diffuseur d'événements.
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subs: make(map[*subscriber]struct{})}
+}
+
+func (b *EventBroker) subscribe(clientID string, admin bool) *subscriber {
+	s := &subscriber{clientID: clientID, admin: admin, ch: make(chan Event, 16)}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+func (b *EventBroker) unsubscribe(s *subscriber) {
+	b.mu.Lock()
+	delete(b.subs, s)
+	b.mu.Unlock()
+}
+
+// Publish envoie l'événement aux administrateurs et au client concerné.
+// Un abonné trop lent perd l'événement plutôt que de bloquer l'appelant.
+func (b *EventBroker) Publish(eventType, clientID string, data interface{}) {
+	if b == nil {
+		return
+	}
+	e := Event{Type: eventType, ClientID: clientID, Data: data, At: time.Now()}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		if !s.admin && (s.clientID == "" || s.clientID != clientID) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			log.Printf("Flux d'événements saturé, événement '%s' ignoré (client: %s)", e.Type, s.clientID)
+		}
+	}
+}
+
+// stream maintient la connexion SSE ouverte et y écrit les événements de l'abonné.
+func (b *EventBroker) stream(w http.ResponseWriter, r *http.Request, s *subscriber) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, r, http.StatusInternalServerError, ErrStreamingUnsupported)
+		return
+	}
+	defer b.unsubscribe(s)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connecté\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case e := <-s.ch:
+			payload, err := json.Marshal(e)
+			if err != nil {
+				log.Printf("Erreur lors de l'encodage de l'événement '%s': %v", e.Type, err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, payload)
+			flusher.Flush()
+		}
+	}
+}
+
+// ClientStreamHandler ouvre le flux des événements concernant le client authentifié.
+// Méthode: GET /api/events
+func (b *EventBroker) ClientStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+	b.stream(w, r, b.subscribe(clientID, false))
+}
+
+// AdminStreamHandler ouvre le flux de tous les événements (nouvelles commandes, réservations, statuts).
+// Méthode: GET /admin/events
+func (b *EventBroker) AdminStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	b.stream(w, r, b.subscribe("", true))
+}
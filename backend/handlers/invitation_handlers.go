@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/messaging"
+	"restaurant-app/backend/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// MessageInvitationPersonnel est la nature des emails d'invitation dans la file d'envoi.
+const MessageInvitationPersonnel = "invitation_personnel"
+
+// Statuts d'une invitation (calculés, non stockés)
+const (
+	InvitationEnAttente = "En attente"
+	InvitationAcceptee  = "Acceptée"
+	InvitationExpiree   = "Expirée"
+	InvitationRevoquee  = "Révoquée"
+)
+
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,6}$`)
+
+var (
+	// errInvitationInvalide est renvoyée pour un code inconnu, révoqué, déjà utilisé ou expiré.
+	errInvitationInvalide = errors.New("invitation invalide ou expirée")
+	// errInvitationAcceptee est renvoyée quand on tente de révoquer une invitation déjà acceptée.
+	errInvitationAcceptee = errors.New("invitation déjà acceptée")
+	// errEmailPris est renvoyée quand un compte existe déjà avec l'email invité.
+	errEmailPris = errors.New("email déjà utilisé")
+)
+
+// InvitationHandler gère les invitations du personnel: un membre autorisé invite une adresse email
+// avec un rôle, et l'invité crée son compte en choisissant lui-même son mot de passe.
+type InvitationHandler struct {
+	DB       *gorm.DB
+	Sessions *SessionHandler
+	TTL      time.Duration // Durée de validité d'une invitation
+	AppURL   string        // Adresse de l'application, pour le lien envoyé par email
+}
+
+// NewInvitationHandler crée une nouvelle instance de InvitationHandler.
+func NewInvitationHandler(db *gorm.DB, sessions *SessionHandler, ttl time.Duration, appURL string) *InvitationHandler {
+	return &InvitationHandler{DB: db, Sessions: sessions, TTL: ttl, AppURL: strings.TrimSuffix(appURL, "/")}
+}
+
+// InvitationView est une invitation telle que renvoyée à l'administration.
+type InvitationView struct {
+	models.InvitationPersonnel
+	Status string `json:"status"`
+}
+
+// CreatedInvitation est la réponse à la création d'une invitation. Le code n'est renvoyé qu'une fois.
+type CreatedInvitation struct {
+	InvitationView
+	Code string `json:"code"`
+	Link string `json:"link"`
+}
+
+func newInvitationView(inv models.InvitationPersonnel, now time.Time) InvitationView {
+	status := InvitationEnAttente
+	switch {
+	case inv.AcceptedAt != nil:
+		status = InvitationAcceptee
+	case inv.RevokedAt != nil:
+		status = InvitationRevoquee
+	case now.After(inv.ExpiresAt):
+		status = InvitationExpiree
+	}
+	return InvitationView{InvitationPersonnel: inv, Status: status}
+}
+
+// invitationLink renvoie le lien d'acceptation envoyé à l'invité.
+func (ih *InvitationHandler) invitationLink(code string) string {
+	return ih.AppURL + "/invitation?code=" + url.QueryEscape(code)
+}
+
+// revokePendingInvitations révoque les invitations encore en attente pour un email.
+func revokePendingInvitations(tx *gorm.DB, email string) error {
+	return tx.Model(&models.InvitationPersonnel{}).
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", email).
+		Update("revoked_at", time.Now()).Error
+}
+
+// ListHandler renvoie les invitations, les plus récentes en premier.
+// Méthode: GET /admin/invitations
+func (ih *InvitationHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	var invitations []models.InvitationPersonnel
+	if err := ih.DB.Order("created_at DESC").Find(&invitations).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération des invitations: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	now := time.Now()
+	views := make([]InvitationView, 0, len(invitations))
+	for _, inv := range invitations {
+		views = append(views, newInvitationView(inv, now))
+	}
+	respondWithJSON(w, http.StatusOK, views)
+}
+
+// CreateHandler invite une adresse email avec un rôle. Une invitation encore en attente pour
+// le même email est remplacée. Le lien et le code sont envoyés par email et renvoyés dans la réponse.
+// Méthode: POST /admin/invitations {"email": ..., "role": ..., "langue": "fr"}
+func (ih *InvitationHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email  string `json:"email"`
+		Role   string `json:"role"`
+		Langue string `json:"langue"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	req.Role = strings.ToLower(strings.TrimSpace(req.Role))
+	switch {
+	case req.Email == "":
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("email", FieldRequired))
+		return
+	case !emailPattern.MatchString(req.Email):
+		respondWithError(w, r, http.StatusBadRequest, ErrEmailInvalid, Field("email", FieldInvalid))
+		return
+	case !ValidRole(req.Role):
+		respondWithError(w, r, http.StatusBadRequest, ErrRoleInvalid, Field("role", FieldInvalid))
+		return
+	case req.Langue != "" && !i18n.Supported(req.Langue):
+		respondWithError(w, r, http.StatusBadRequest, ErrLanguageUnsupported, Field("langue", FieldInvalid))
+		return
+	}
+
+	code, err := newToken("inv_")
+	if err != nil {
+		log.Printf("Erreur lors de la génération du code d'invitation: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	actor := ActorFromContext(r.Context())
+	lang := i18n.Resolve(req.Langue, i18n.FromAcceptLanguage(r.Header.Get("Accept-Language")))
+	invitation := models.InvitationPersonnel{
+		Email:     req.Email,
+		Role:      req.Role,
+		Langue:    lang,
+		CodeHash:  hashToken(code),
+		InvitedBy: actor.ID,
+		ExpiresAt: time.Now().Add(ih.TTL),
+	}
+
+	err = ih.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Client{}).Where("email = ?", req.Email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errEmailPris
+		}
+		if err := revokePendingInvitations(tx, req.Email); err != nil {
+			return err
+		}
+		if err := tx.Create(&invitation).Error; err != nil {
+			return err
+		}
+		if err := RecordAudit(tx, r, AuditCreate, AuditInvitation, invitation.ID, nil, invitation); err != nil {
+			return err
+		}
+		return enqueueTemplate(tx, messaging.ChannelEmail, invitation.Email, i18n.EmailInvitationPersonnel,
+			lang, MessageInvitationPersonnel, invitation.ID, map[string]interface{}{
+				"Role":       i18n.RoleLabel(invitation.Role, lang),
+				"Lien":       ih.invitationLink(code),
+				"Code":       code,
+				"Expiration": i18n.FormatDate(invitation.ExpiresAt, lang),
+			})
+	})
+	if err != nil {
+		if errors.Is(err, errEmailPris) {
+			// Compte existant: le rôle s'attribue directement par /admin/staff/{id}
+			respondWithError(w, r, http.StatusConflict, ErrEmailTaken, Field("email", FieldInvalid))
+			return
+		}
+		log.Printf("Erreur DB lors de la création de l'invitation (%s): %v", req.Email, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, CreatedInvitation{
+		InvitationView: newInvitationView(invitation, time.Now()),
+		Code:           code,
+		Link:           ih.invitationLink(code),
+	})
+	log.Printf("Invitation envoyée à %s (rôle: %s) par %s", invitation.Email, invitation.Role, actor.ID)
+}
+
+// ItemHandler révoque une invitation encore en attente.
+// Méthode: DELETE /admin/invitations/{id}
+func (ih *InvitationHandler) ItemHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	// Attendu: ["", "admin", "invitations", id]
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[3] == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+
+	err := ih.DB.Transaction(func(tx *gorm.DB) error {
+		var invitation models.InvitationPersonnel
+		if err := tx.First(&invitation, "id = ?", parts[3]).Error; err != nil {
+			return err
+		}
+		if invitation.AcceptedAt != nil {
+			return errInvitationAcceptee
+		}
+		if invitation.RevokedAt != nil {
+			return nil // Déjà révoquée
+		}
+		before := invitation
+		if err := tx.Model(&invitation).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, r, AuditRevoke, AuditInvitation, invitation.ID, before, invitation)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondWithError(w, r, http.StatusNotFound, ErrInvitationNotFound)
+		case errors.Is(err, errInvitationAcceptee):
+			respondWithError(w, r, http.StatusConflict, ErrInvitationAccepted)
+		default:
+			log.Printf("Erreur DB lors de la révocation de l'invitation (ID: %s): %v", parts[3], err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AcceptHandler crée le compte de l'invité avec le mot de passe qu'il a choisi et le rôle de l'invitation,
+// puis ouvre sa session (même réponse que /login).
+// Méthode: POST /invitations/accept {"code": ..., "motDePasse": ..., "nomClient": ..., "prenomClient": ..., "numTel": ...}
+func (ih *InvitationHandler) AcceptHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Code         string `json:"code"`
+		MotDePasse   string `json:"motDePasse"`
+		NomClient    string `json:"nomClient"`
+		PrenomClient string `json:"prenomClient"`
+		NumTel       string `json:"numTel"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	var details []FieldError
+	for _, f := range []struct{ name, value string }{
+		{"code", req.Code}, {"motDePasse", req.MotDePasse}, {"nomClient", req.NomClient}, {"prenomClient", req.PrenomClient},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			details = append(details, Field(f.name, FieldRequired))
+		}
+	}
+	if len(details) > 0 {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, details...)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.MotDePasse), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Erreur lors du hachage du mot de passe de l'invité: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	var client models.Client
+	var tokens TokenPair
+	err = ih.DB.Transaction(func(tx *gorm.DB) error {
+		var invitation models.InvitationPersonnel
+		if err := tx.Where("code_hash = ?", hashToken(strings.TrimSpace(req.Code))).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvitationInvalide
+			}
+			return err
+		}
+		if newInvitationView(invitation, time.Now()).Status != InvitationEnAttente {
+			return errInvitationInvalide
+		}
+		var count int64
+		if err := tx.Model(&models.Client{}).Where("email = ?", invitation.Email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errEmailPris
+		}
+
+		client = models.Client{
+			Email:            invitation.Email,
+			NomClient:        req.NomClient,
+			PrenomClient:     req.PrenomClient,
+			NumTel:           req.NumTel,
+			MotDePasseHashed: string(hashedPassword),
+			Role:             invitation.Role,
+			IsAdmin:          true,
+			EmailVerifie:     true, // Le code a été reçu à cette adresse
+			Langue:           i18n.Resolve(invitation.Langue),
+		}
+		if err := tx.Create(&client).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&invitation).Updates(map[string]interface{}{"accepted_at": time.Now(), "client_id": client.ID}).Error; err != nil {
+			return err
+		}
+		var err error
+		tokens, err = ih.Sessions.createSession(tx, client.ID, r)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvitationInvalide):
+			respondWithError(w, r, http.StatusBadRequest, ErrInvitationInvalid, Field("code", FieldInvalid))
+		case errors.Is(err, errEmailPris):
+			respondWithError(w, r, http.StatusConflict, ErrEmailTaken)
+		default:
+			log.Printf("Erreur DB lors de l'acceptation d'une invitation: %v", err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, LoginResponse(client, tokens))
+	log.Printf("Invitation acceptée: compte %s créé (rôle: %s)", client.Email, client.Role)
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// errLigneIntrouvable est renvoyée quand la ligne à marquer prête n'existe pas dans le ticket.
+var errLigneIntrouvable = errors.New("ligne de commande introuvable")
+
+// kitchenStatuses sont les statuts des commandes affichées en cuisine.
+var kitchenStatuses = []string{models.CommandeConfirmee, models.CommandeEnPreparation}
+
+// KitchenHandler regroupe les dépendances et les méthodes de l'écran cuisine (KDS).
+type KitchenHandler struct {
+	DB     *gorm.DB
+	Events *EventBroker
+}
+
+// NewKitchenHandler crée une nouvelle instance de KitchenHandler.
+func NewKitchenHandler(db *gorm.DB, events *EventBroker) *KitchenHandler {
+	return &KitchenHandler{DB: db, Events: events}
+}
+
+// KitchenLine est une ligne de ticket vue par la cuisine.
+type KitchenLine struct {
+	PlatID   string     `json:"plat_id"`
+	Name     string     `json:"name"`
+	Station  string     `json:"station"` // Poste de préparation (catégorie du plat)
+	Quantity int        `json:"quantity"`
+	Ready    bool       `json:"ready"`
+	ReadyAt  *time.Time `json:"ready_at"`
+}
+
+// KitchenTicket est une commande confirmée en attente de préparation.
+type KitchenTicket struct {
+	CommandeID     string        `json:"commande_id"`
+	Status         string        `json:"status"`
+	OrderDate      time.Time     `json:"order_date"`
+	ConfirmedAt    time.Time     `json:"confirmed_at"`
+	WaitingSeconds int           `json:"waiting_seconds"` // Temps écoulé depuis la confirmation
+	Lines          []KitchenLine `json:"lines"`
+}
+
+// KitchenStation regroupe les tickets qui ont au moins une ligne pour un poste.
+type KitchenStation struct {
+	Station      string          `json:"station"`
+	PendingLines int             `json:"pending_lines"`
+	Tickets      []KitchenTicket `json:"tickets"`
+}
+
+// stationOf renvoie le poste d'une ligne (catégorie copiée à la commande).
+func stationOf(l models.CommandePlat) string {
+	if l.PlatCategory == "" {
+		return "Autres"
+	}
+	return l.PlatCategory
+}
+
+// confirmedAt renvoie l'heure de confirmation d'une commande (date de commande à défaut).
+func confirmedAt(c models.Commande) time.Time {
+	for i := len(c.Historique) - 1; i >= 0; i-- {
+		if c.Historique[i].ToStatus == models.CommandeConfirmee {
+			return c.Historique[i].CreatedAt
+		}
+	}
+	return c.OrderDate
+}
+
+// buildTicket construit le ticket d'une commande. Si station n'est pas vide,
+// seules les lignes de ce poste sont gardées.
+func buildTicket(c models.Commande, station string, now time.Time) KitchenTicket {
+	confirmed := confirmedAt(c)
+	ticket := KitchenTicket{
+		CommandeID:     c.ID,
+		Status:         c.Status,
+		OrderDate:      c.OrderDate,
+		ConfirmedAt:    confirmed,
+		WaitingSeconds: int(now.Sub(confirmed).Seconds()),
+		Lines:          []KitchenLine{},
+	}
+	for _, l := range c.Lignes {
+		if station != "" && !strings.EqualFold(stationOf(l), station) {
+			continue
+		}
+		ticket.Lines = append(ticket.Lines, KitchenLine{
+			PlatID:   l.PlatID,
+			Name:     l.PlatName,
+			Station:  stationOf(l),
+			Quantity: l.Quantity,
+			Ready:    l.ReadyAt != nil,
+			ReadyAt:  l.ReadyAt,
+		})
+	}
+	sort.SliceStable(ticket.Lines, func(i, j int) bool { return ticket.Lines[i].Station < ticket.Lines[j].Station })
+	return ticket
+}
+
+// loadKitchenOrders charge les commandes à préparer, les plus anciennes en premier.
+func (kh *KitchenHandler) loadKitchenOrders() ([]models.Commande, error) {
+	var commandes []models.Commande
+	err := kh.DB.Preload("Lignes").
+		Preload("Historique", func(tx *gorm.DB) *gorm.DB { return tx.Order("created_at ASC") }).
+		Where("status IN ?", kitchenStatuses).
+		Order("order_date ASC").
+		Find(&commandes).Error
+	return commandes, err
+}
+
+// ListTicketsHandler renvoie la file des tickets, avec un filtre optionnel par poste.
+// Méthode: GET /kitchen/tickets?station={categorie}
+func (kh *KitchenHandler) ListTicketsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	commandes, err := kh.loadKitchenOrders()
+	if err != nil {
+		log.Printf("Erreur DB lors de la récupération des tickets cuisine: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	station := r.URL.Query().Get("station")
+	now := time.Now()
+	tickets := []KitchenTicket{}
+	for _, c := range commandes {
+		if ticket := buildTicket(c, station, now); len(ticket.Lines) > 0 {
+			tickets = append(tickets, ticket)
+		}
+	}
+	respondWithJSON(w, http.StatusOK, tickets)
+}
+
+// ListStationsHandler renvoie les tickets regroupés par poste (catégorie de plat).
+// Méthode: GET /kitchen/stations
+func (kh *KitchenHandler) ListStationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	commandes, err := kh.loadKitchenOrders()
+	if err != nil {
+		log.Printf("Erreur DB lors de la récupération des tickets cuisine: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	now := time.Now()
+	byStation := make(map[string]*KitchenStation)
+	for _, c := range commandes {
+		seen := make(map[string]bool)
+		for _, l := range c.Lignes {
+			name := stationOf(l)
+			st, ok := byStation[name]
+			if !ok {
+				st = &KitchenStation{Station: name, Tickets: []KitchenTicket{}}
+				byStation[name] = st
+			}
+			if l.ReadyAt == nil {
+				st.PendingLines += l.Quantity
+			}
+			if !seen[name] {
+				seen[name] = true
+				st.Tickets = append(st.Tickets, buildTicket(c, name, now))
+			}
+		}
+	}
+
+	stations := make([]KitchenStation, 0, len(byStation))
+	for _, st := range byStation {
+		stations = append(stations, *st)
+	}
+	sort.Slice(stations, func(i, j int) bool { return stations[i].Station < stations[j].Station })
+	respondWithJSON(w, http.StatusOK, stations)
+}
+
+// advanceForKitchen met à jour le statut de la commande après un marquage en cuisine:
+// la commande passe "En préparation" au premier marquage, puis "Prête" quand toutes les lignes le sont.
+func advanceForKitchen(tx *gorm.DB, commande *models.Commande, actor Actor) (bool, error) {
+	changed := false
+	if commande.Status == models.CommandeConfirmee {
+		if err := changeCommandeStatus(tx, commande, models.CommandeEnPreparation, actor, "Préparation commencée en cuisine"); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	for _, l := range commande.Lignes {
+		if l.ReadyAt == nil {
+			return changed, nil
+		}
+	}
+	if err := changeCommandeStatus(tx, commande, models.CommandePrete, actor, "Ticket terminé en cuisine"); err != nil {
+		return changed, err
+	}
+	return true, nil
+}
+
+// BumpHandler marque prête une ligne (/kitchen/tickets/{id}/lines/{platID}/bump)
+// ou tout le ticket (/kitchen/tickets/{id}/bump), et fait avancer le statut de la commande.
+// Méthode: POST
+func (kh *KitchenHandler) BumpHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	// Attendu: ["", "kitchen", "tickets", id, "bump"] ou ["", "kitchen", "tickets", id, "lines", platID, "bump"]
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 5 || parts[3] == "" || parts[len(parts)-1] != "bump" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	commandeID := parts[3]
+	platID := ""
+	if len(parts) == 7 && parts[4] == "lines" {
+		platID = parts[5]
+	} else if len(parts) != 5 {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+
+	actor := ActorFromContext(r.Context())
+	var commande models.Commande
+	statusChanged := false
+	err := kh.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if commande, err = loadCommande(tx, commandeID); err != nil {
+			return err
+		}
+		if commande.Status != models.CommandeConfirmee && commande.Status != models.CommandeEnPreparation {
+			return &TransitionError{From: commande.Status, To: models.CommandePrete}
+		}
+
+		now := time.Now()
+		found := false
+		for i := range commande.Lignes {
+			l := &commande.Lignes[i]
+			if platID != "" && l.PlatID != platID {
+				continue
+			}
+			found = true
+			if l.ReadyAt != nil {
+				continue // Déjà prête
+			}
+			l.ReadyAt = &now
+			if err := tx.Model(&models.CommandePlat{}).
+				Where("commande_id = ? AND plat_id = ?", l.CommandeID, l.PlatID).
+				Update("ready_at", now).Error; err != nil {
+				return err
+			}
+		}
+		if platID != "" && !found {
+			return errLigneIntrouvable
+		}
+
+		from := commande.Status
+		if statusChanged, err = advanceForKitchen(tx, &commande, actor); err != nil || !statusChanged {
+			return err
+		}
+		return RecordAudit(tx, r, AuditStatusChange, AuditCommande, commande.ID,
+			map[string]interface{}{"status": from}, map[string]interface{}{"status": commande.Status})
+	})
+	if err != nil {
+		var transitionErr *TransitionError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, errLigneIntrouvable):
+			respondWithError(w, r, http.StatusNotFound, ErrTicketNotFound)
+		case errors.As(err, &transitionErr):
+			respondWithErrorParams(w, r, http.StatusConflict, ErrOrderNotInKitchen, map[string]string{"from": transitionErr.From})
+		default:
+			log.Printf("Erreur DB lors du marquage en cuisine (commande: %s): %v", commandeID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		}
+		return
+	}
+
+	if statusChanged {
+		kh.Events.Publish(EventOrderStatusChanged, commande.ClientID, commande)
+	}
+	respondWithJSON(w, http.StatusOK, buildTicket(commande, "", time.Now()))
+	log.Printf("Ticket cuisine mis à jour (commande: %s, plat: '%s', statut: %s)", commande.ID, platID, commande.Status)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"restaurant-app/backend/models"
+)
+
+func TestBumpHandlerRecordsAudit(t *testing.T) {
+	db := newTestDB(t)
+	kh := NewKitchenHandler(db, NewEventBroker())
+	client := seedClient(t, db, "client@test.fr")
+	chef := seedClient(t, db, "chef@test.fr")
+	chef.Role = models.RoleChef
+	commande := seedCommande(t, db, client.ID, models.CommandeConfirmee, 24, 24)
+	for _, platID := range []string{"plat-1", "plat-2"} {
+		ligne := models.CommandePlat{CommandeID: commande.ID, PlatID: platID, Quantity: 1, UnitPrice: 12, PlatName: platID}
+		if err := db.Create(&ligne).Error; err != nil {
+			t.Fatalf("création de la ligne: %v", err)
+		}
+	}
+	bump := func(path string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req = req.WithContext(WithStaff(req.Context(), chef))
+		rec := httptest.NewRecorder()
+		kh.BumpHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: statut %d, attendu %d (%s)", path, rec.Code, http.StatusOK, rec.Body.String())
+		}
+	}
+	entries := func() []models.JournalAudit {
+		t.Helper()
+		var entries []models.JournalAudit
+		db.Where("entity_type = ? AND entity_id = ?", AuditCommande, commande.ID).Order("created_at").Find(&entries)
+		return entries
+	}
+
+	bump("/kitchen/tickets/" + commande.ID + "/lines/plat-1/bump")
+	got := entries()
+	if len(got) != 1 || got[0].Action != AuditStatusChange || got[0].ActorID != chef.ID ||
+		!strings.Contains(string(got[0].Changes), `"status":{"before":"`+models.CommandeConfirmee+`","after":"`+models.CommandeEnPreparation+`"}`) {
+		t.Fatalf("après le premier marquage: %+v, attendu le passage en préparation par %s", got, chef.ID)
+	}
+	// Une ligne déjà prête ne change pas le statut: rien n'est ajouté au journal
+	bump("/kitchen/tickets/" + commande.ID + "/lines/plat-1/bump")
+	if got := entries(); len(got) != 1 {
+		t.Fatalf("marquage sans changement de statut: %d entrées d'audit, attendu 1", len(got))
+	}
+	bump("/kitchen/tickets/" + commande.ID + "/bump")
+	if got := entries(); len(got) != 2 ||
+		!strings.Contains(string(got[1].Changes), `"after":"`+models.CommandePrete+`"`) {
+		t.Errorf("après le dernier marquage: %+v, attendu le passage à %s", got, models.CommandePrete)
+	}
+}
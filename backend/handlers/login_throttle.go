@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/messaging"
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// MessageCompteVerrouille est la nature des emails de verrouillage dans la file d'envoi.
+const MessageCompteVerrouille = "compte_verrouille"
+
+// loginBaseDelay est l'attente imposée après le premier échec compté; elle double à chaque échec suivant.
+const loginBaseDelay = time.Second
+
+// ipFreeFailures est le nombre d'échecs tolérés sans attente pour une adresse IP,
+// partagée parfois par plusieurs clients (wifi du restaurant, opérateur mobile).
+const ipFreeFailures = 5
+
+// Demandes de réinitialisation du mot de passe acceptées avant blocage (dont les premières sans attente),
+// par email et par adresse IP, sur la durée d'un verrouillage.
+const (
+	resetMaxRequests    = 3
+	resetFreeRequests   = 1
+	resetIPMaxRequests  = 10
+	resetIPFreeRequests = 5
+)
+
+// LoginThrottle limite les tentatives de connexion échouées par email et par adresse IP:
+// attente exponentielle entre deux essais, puis verrouillage temporaire après trop d'échecs.
+// Les compteurs sont enregistrés en base (LimiteConnexion).
+type LoginThrottle struct {
+	DB            *gorm.DB
+	MaxFailures   int           // Échecs avant le verrouillage d'un email
+	IPMaxFailures int           // Échecs avant le blocage d'une adresse IP
+	LockDuration  time.Duration // Durée d'un verrouillage; les échecs plus anciens sont oubliés
+}
+
+// NewLoginThrottle crée une nouvelle instance de LoginThrottle.
+func NewLoginThrottle(db *gorm.DB, maxFailures, ipMaxFailures int, lockDuration time.Duration) *LoginThrottle {
+	return &LoginThrottle{DB: db, MaxFailures: maxFailures, IPMaxFailures: ipMaxFailures, LockDuration: lockDuration}
+}
+
+// normalizeEmail renvoie l'email tel qu'utilisé comme clé des compteurs.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// backoff renvoie l'attente imposée après un nombre d'échecs, au-delà des échecs tolérés.
+func (lt *LoginThrottle) backoff(failures, free int) time.Duration {
+	if failures <= free {
+		return 0
+	}
+	d := loginBaseDelay
+	for i := 1; i < failures-free && d < lt.LockDuration; i++ {
+		d *= 2
+	}
+	if d > lt.LockDuration {
+		d = lt.LockDuration
+	}
+	return d
+}
+
+// Check renvoie l'attente restante avant qu'une connexion puisse être tentée pour cet email
+// depuis l'adresse IP de la requête (0 si l'essai est permis).
+func (lt *LoginThrottle) Check(r *http.Request, email string) (time.Duration, error) {
+	return lt.wait(lt.DB, models.LimiteParEmail, normalizeEmail(email), models.LimiteParIP, clientIP(r))
+}
+
+// wait renvoie l'attente la plus longue imposée à l'email ou à l'adresse IP pour ces types de compteurs.
+func (lt *LoginThrottle) wait(tx *gorm.DB, emailKind, email, ipKind, ip string) (time.Duration, error) {
+	var limites []models.LimiteConnexion
+	err := tx.Where("(kind = ? AND value = ?) OR (kind = ? AND value = ?)",
+		emailKind, email, ipKind, ip).Find(&limites).Error
+	if err != nil {
+		return 0, err
+	}
+	var wait time.Duration
+	now := time.Now()
+	for _, l := range limites {
+		if d := l.BlockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// recordFailure compte un échec pour une clé et renvoie le compteur à jour.
+// locked vaut true si cet échec vient de déclencher le verrouillage.
+func (lt *LoginThrottle) recordFailure(tx *gorm.DB, kind, value string, maxFailures, free int) (limite models.LimiteConnexion, locked bool, err error) {
+	now := time.Now()
+	err = tx.Where("kind = ? AND value = ?", kind, value).First(&limite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		limite = models.LimiteConnexion{Kind: kind, Value: value}
+	} else if err != nil {
+		return limite, false, err
+	}
+
+	// Les échecs anciens (ou antérieurs à un verrouillage terminé) sont oubliés
+	if now.Sub(limite.LastFailureAt) > lt.LockDuration {
+		limite.Failures = 0
+		limite.LockedAt = nil
+	}
+	limite.Failures++
+	limite.LastFailureAt = now
+	if limite.Failures >= maxFailures {
+		limite.BlockedUntil = now.Add(lt.LockDuration)
+		if limite.LockedAt == nil {
+			limite.LockedAt = &now
+			locked = true
+		}
+	} else {
+		limite.BlockedUntil = now.Add(lt.backoff(limite.Failures, free))
+	}
+	return limite, locked, tx.Save(&limite).Error
+}
+
+// RecordFailure compte un échec de connexion pour l'email et pour l'adresse IP de la requête.
+// Quand l'email d'un compte existant est verrouillé, le client est prévenu (notification et email).
+func (lt *LoginThrottle) RecordFailure(r *http.Request, email string) error {
+	email, ip := normalizeEmail(email), clientIP(r)
+	return lt.DB.Transaction(func(tx *gorm.DB) error {
+		if _, locked, err := lt.recordFailure(tx, models.LimiteParIP, ip, lt.IPMaxFailures, ipFreeFailures); err != nil {
+			return err
+		} else if locked {
+			log.Printf("Adresse IP %s bloquée après %d échecs de connexion", ip, lt.IPMaxFailures)
+		}
+		if email == "" {
+			return nil
+		}
+		limite, locked, err := lt.recordFailure(tx, models.LimiteParEmail, email, lt.MaxFailures, 0)
+		if err != nil || !locked {
+			return err
+		}
+		log.Printf("Connexion verrouillée pour %s après %d échecs (jusqu'à %s)", email, limite.Failures, limite.BlockedUntil.Format(time.RFC3339))
+		return notifyAccountLocked(tx, limite)
+	})
+}
+
+// RecordSuccess oublie les échecs de l'email après une connexion réussie.
+// Le compteur de l'adresse IP n'est pas remis à zéro: un compte valide ne doit pas servir à débloquer une IP.
+func (lt *LoginThrottle) RecordSuccess(email string) error {
+	return lt.DB.Where("kind = ? AND value = ?", models.LimiteParEmail, normalizeEmail(email)).
+		Delete(&models.LimiteConnexion{}).Error
+}
+
+// ThrottleReset compte une demande de réinitialisation du mot de passe pour l'email et pour l'adresse IP
+// de la requête, et renvoie l'attente restante si la demande doit être refusée (elle n'est alors pas comptée).
+// Le compteur ne dépend pas de l'existence du compte, pour ne pas la révéler.
+func (lt *LoginThrottle) ThrottleReset(r *http.Request, email string) (time.Duration, error) {
+	email, ip := normalizeEmail(email), clientIP(r)
+	var wait time.Duration
+	err := lt.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if wait, err = lt.wait(tx, models.LimiteReinitEmail, email, models.LimiteReinitIP, ip); err != nil || wait > 0 {
+			return err
+		}
+		if _, _, err := lt.recordFailure(tx, models.LimiteReinitIP, ip, resetIPMaxRequests, resetIPFreeRequests); err != nil {
+			return err
+		}
+		_, locked, err := lt.recordFailure(tx, models.LimiteReinitEmail, email, resetMaxRequests, resetFreeRequests)
+		if locked {
+			log.Printf("Demandes de réinitialisation bloquées pour %s après %d demandes", email, resetMaxRequests)
+		}
+		return err
+	})
+	return wait, err
+}
+
+// notifyAccountLocked prévient le titulaire du compte verrouillé, s'il existe.
+func notifyAccountLocked(tx *gorm.DB, limite models.LimiteConnexion) error {
+	var client models.Client
+	err := tx.Where("LOWER(email) = ?", limite.Value).First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // Email inconnu: personne à prévenir
+	}
+	if err != nil {
+		return err
+	}
+	lang := i18n.Resolve(client.Langue)
+	vars := map[string]interface{}{
+		"Nom":    client.PrenomClient,
+		"Echecs": limite.Failures,
+		"Jusqua": i18n.FormatDate(limite.BlockedUntil, lang),
+	}
+	message, err := renderNotification(tx, i18n.NotifCompteVerrouille, lang, vars)
+	if err != nil {
+		return err
+	}
+	if err := CreateNotification(tx, client.ID, models.NotificationCompte, limite.ID, message); err != nil {
+		return err
+	}
+	return enqueueTemplate(tx, messaging.ChannelEmail, client.Email, i18n.EmailCompteVerrouille,
+		lang, MessageCompteVerrouille, limite.ID, vars)
+}
+
+// RespondThrottled répond 429 avec l'attente restante (en-tête Retry-After, en secondes).
+func RespondThrottled(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	seconds := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+	w.Header().Set("Retry-After", seconds)
+	respondWithErrorParams(w, r, http.StatusTooManyRequests, ErrTooManyAttempts, map[string]string{"seconds": seconds})
+}
+
+// LockoutView est un compteur d'échecs tel que renvoyé à l'administration.
+type LockoutView struct {
+	models.LimiteConnexion
+	Active            bool `json:"active"`              // Les essais sont actuellement refusés
+	Locked            bool `json:"locked"`              // Verrouillage (et non simple attente entre deux essais)
+	RetryAfterSeconds int  `json:"retry_after_seconds"` // Attente restante
+}
+
+// ListLockoutsHandler renvoie les emails et adresses IP actuellement bloqués, les plus récents en premier.
+// Avec ?all=true, tous les compteurs d'échecs sont renvoyés. Filtre optionnel ?kind=email|ip|rst_email|rst_ip.
+// Méthode: GET /admin/lockouts
+func (lt *LoginThrottle) ListLockoutsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	now := time.Now()
+	query := lt.DB.Order("last_failure_at DESC")
+	if r.URL.Query().Get("all") != "true" {
+		query = query.Where("blocked_until > ?", now)
+	}
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var limites []models.LimiteConnexion
+	if err := query.Find(&limites).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération des verrouillages: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	views := make([]LockoutView, 0, len(limites))
+	for _, l := range limites {
+		view := LockoutView{LimiteConnexion: l, Active: l.BlockedUntil.After(now), Locked: l.LockedAt != nil}
+		if view.Active {
+			view.RetryAfterSeconds = int(math.Ceil(l.BlockedUntil.Sub(now).Seconds()))
+		}
+		views = append(views, view)
+	}
+	respondWithJSON(w, http.StatusOK, views)
+}
+
+// lockoutAuditView renvoie l'état d'un compteur tel qu'il est enregistré dans le journal d'audit.
+// Un compteur par email n'y figure pas avec l'email, mais avec l'ID du compte s'il existe:
+// le journal ne garde pas d'adresse que l'effacement des données du client devrait retrouver.
+func lockoutAuditView(tx *gorm.DB, limite models.LimiteConnexion) (map[string]interface{}, error) {
+	view := map[string]interface{}{
+		"kind":            limite.Kind,
+		"failures":        limite.Failures,
+		"last_failure_at": limite.LastFailureAt,
+		"blocked_until":   limite.BlockedUntil,
+		"locked_at":       limite.LockedAt,
+	}
+	if limite.Kind == models.LimiteParIP || limite.Kind == models.LimiteReinitIP {
+		view["value"] = limite.Value
+		return view, nil
+	}
+	var client models.Client
+	err := tx.Select("id").Where("LOWER(email) = ?", limite.Value).First(&client).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	view["client_id"] = client.ID
+	return view, nil
+}
+
+// ClearLockoutHandler supprime un compteur d'échecs: l'email ou l'adresse IP peut de nouveau se connecter.
+// Méthode: DELETE /admin/lockouts/{id}
+func (lt *LoginThrottle) ClearLockoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	// Attendu: ["", "admin", "lockouts", id]
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[3] == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+
+	err := lt.DB.Transaction(func(tx *gorm.DB) error {
+		var limite models.LimiteConnexion
+		if err := tx.First(&limite, "id = ?", parts[3]).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&limite).Error; err != nil {
+			return err
+		}
+		view, err := lockoutAuditView(tx, limite)
+		if err != nil {
+			return err
+		}
+		return RecordAudit(tx, r, AuditDelete, AuditLockout, limite.ID, view, nil)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusNotFound, ErrLockoutNotFound)
+			return
+		}
+		log.Printf("Erreur DB lors de la levée du verrouillage (ID: %s): %v", parts[3], err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("Verrouillage de connexion levé (ID: %s) par %s", parts[3], ActorFromContext(r.Context()).ID)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// loginRequest renvoie une requête de connexion venant de l'adresse IP donnée.
+func loginRequest(ip string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = ip + ":40000"
+	return req
+}
+
+// limite renvoie le compteur d'un email ou d'une adresse IP.
+func limite(t *testing.T, db *gorm.DB, kind, value string) models.LimiteConnexion {
+	t.Helper()
+	var l models.LimiteConnexion
+	if err := db.Where("kind = ? AND value = ?", kind, value).First(&l).Error; err != nil {
+		t.Fatalf("compteur %s %s: %v", kind, value, err)
+	}
+	return l
+}
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	lt := &LoginThrottle{LockDuration: 15 * time.Second}
+	tests := []struct {
+		failures, free int
+		want           time.Duration
+	}{
+		{0, 0, 0},
+		{1, 0, time.Second},
+		{2, 0, 2 * time.Second},
+		{3, 0, 4 * time.Second},
+		{4, 0, 8 * time.Second},
+		{5, 0, 15 * time.Second}, // 16s plafonnées à la durée du verrouillage
+		{40, 0, 15 * time.Second},
+		{5, 5, 0}, // Échecs tolérés (adresse IP)
+		{6, 5, time.Second},
+		{8, 5, 4 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := lt.backoff(tt.failures, tt.free); got != tt.want {
+			t.Errorf("backoff(%d, %d) = %v, attendu %v", tt.failures, tt.free, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleLocksEmail(t *testing.T) {
+	db := newTestDB(t)
+	lt := NewLoginThrottle(db, 3, 20, time.Minute)
+	req := loginRequest("10.0.0.1")
+
+	for i := 1; i <= 2; i++ {
+		if err := lt.RecordFailure(req, "Client@Test.fr"); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+		l := limite(t, db, models.LimiteParEmail, "client@test.fr")
+		if l.Failures != i || l.LockedAt != nil {
+			t.Fatalf("après %d échecs: %+v, attendu non verrouillé", i, l)
+		}
+		wait, err := lt.Check(req, "client@test.fr")
+		if err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+		// Attente doublée à chaque échec (1s puis 2s)
+		if max := lt.backoff(i, 0); wait <= 0 || wait > max {
+			t.Errorf("après %d échecs: attente %v, attendu dans ]0, %v]", i, wait, max)
+		}
+	}
+
+	if err := lt.RecordFailure(req, "client@test.fr"); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	l := limite(t, db, models.LimiteParEmail, "client@test.fr")
+	if l.LockedAt == nil {
+		t.Fatalf("après 3 échecs: %+v, attendu verrouillé", l)
+	}
+	if wait, _ := lt.Check(loginRequest("10.0.0.2"), "client@test.fr"); wait < 59*time.Second {
+		t.Errorf("email verrouillé: attente %v depuis une autre adresse IP, attendu la durée du verrouillage", wait)
+	}
+	// Le verrouillage d'un email ne bloque pas les autres comptes depuis la même adresse
+	if wait, _ := lt.Check(req, "autre@test.fr"); wait != 0 {
+		t.Errorf("autre email: attente %v, attendu 0", wait)
+	}
+}
+
+func TestLoginThrottleLockExpiry(t *testing.T) {
+	db := newTestDB(t)
+	lt := NewLoginThrottle(db, 3, 20, time.Minute)
+	req := loginRequest("10.0.0.1")
+	for i := 0; i < 3; i++ {
+		if err := lt.RecordFailure(req, "client@test.fr"); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+
+	// Fin du verrouillage: les essais sont de nouveau permis
+	past := time.Now().Add(-time.Second)
+	db.Model(&models.LimiteConnexion{}).Where("kind = ?", models.LimiteParEmail).Update("blocked_until", past)
+	if wait, _ := lt.Check(req, "client@test.fr"); wait != 0 {
+		t.Fatalf("verrouillage expiré: attente %v, attendu 0", wait)
+	}
+
+	// Un échec juste après le verrouillage le prolonge sans repartir de zéro
+	if err := lt.RecordFailure(req, "client@test.fr"); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	if l := limite(t, db, models.LimiteParEmail, "client@test.fr"); l.Failures != 4 || l.LockedAt == nil {
+		t.Errorf("échec pendant la fenêtre: %+v, attendu 4 échecs et verrouillé", l)
+	}
+
+	// Au-delà de LockDuration depuis le dernier échec, le compteur repart de zéro
+	old := time.Now().Add(-2 * time.Minute)
+	db.Model(&models.LimiteConnexion{}).Where("kind = ?", models.LimiteParEmail).
+		Updates(map[string]interface{}{"last_failure_at": old, "blocked_until": old})
+	if err := lt.RecordFailure(req, "client@test.fr"); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	if l := limite(t, db, models.LimiteParEmail, "client@test.fr"); l.Failures != 1 || l.LockedAt != nil {
+		t.Errorf("échec après LockDuration: %+v, attendu 1 échec et non verrouillé", l)
+	}
+}
+
+func TestLoginThrottleSeparateThresholds(t *testing.T) {
+	db := newTestDB(t)
+	lt := NewLoginThrottle(db, 3, 7, time.Minute)
+	req := loginRequest("10.0.0.1")
+
+	// Un email différent à chaque essai: aucun email n'atteint son seuil, l'adresse IP si
+	for i := 1; i <= 7; i++ {
+		email := "client" + string(rune('a'+i)) + "@test.fr"
+		if err := lt.RecordFailure(req, email); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+		ip := limite(t, db, models.LimiteParIP, "10.0.0.1")
+		if ip.Failures != i {
+			t.Fatalf("adresse IP: %d échecs, attendu %d", ip.Failures, i)
+		}
+		// Les échecs tolérés pour une adresse IP n'imposent aucune attente
+		if i <= ipFreeFailures && ip.BlockedUntil.After(time.Now()) {
+			t.Errorf("adresse IP après %d échecs: bloquée jusqu'à %v, attendu aucune attente", i, ip.BlockedUntil)
+		}
+		if i < 7 && ip.LockedAt != nil {
+			t.Errorf("adresse IP verrouillée après %d échecs, attendu 7", i)
+		}
+		if l := limite(t, db, models.LimiteParEmail, email); l.Failures != 1 || l.LockedAt != nil {
+			t.Errorf("email %s: %+v, attendu 1 échec", email, l)
+		}
+	}
+	if ip := limite(t, db, models.LimiteParIP, "10.0.0.1"); ip.LockedAt == nil {
+		t.Fatalf("adresse IP non verrouillée après 7 échecs")
+	}
+	// L'adresse bloquée refuse tous les emails; une autre adresse reste permise pour un email sain
+	if wait, _ := lt.Check(req, "nouveau@test.fr"); wait < 59*time.Second {
+		t.Errorf("adresse IP verrouillée: attente %v, attendu la durée du verrouillage", wait)
+	}
+	if wait, _ := lt.Check(loginRequest("10.0.0.2"), "nouveau@test.fr"); wait != 0 {
+		t.Errorf("autre adresse IP: attente %v, attendu 0", wait)
+	}
+
+	// Une connexion réussie oublie les échecs de l'email mais pas ceux de l'adresse IP
+	if err := lt.RecordSuccess("clientb@test.fr"); err != nil {
+		t.Fatalf("RecordSuccess: %v", err)
+	}
+	var count int64
+	db.Model(&models.LimiteConnexion{}).Where("kind = ? AND value = ?", models.LimiteParEmail, "clientb@test.fr").Count(&count)
+	if count != 0 {
+		t.Errorf("compteur de l'email conservé après une connexion réussie")
+	}
+	if ip := limite(t, db, models.LimiteParIP, "10.0.0.1"); ip.LockedAt == nil {
+		t.Errorf("compteur de l'adresse IP levé par une connexion réussie")
+	}
+}
+
+func TestClearLockoutAuditOmitsEmail(t *testing.T) {
+	db := newTestDB(t)
+	lt := NewLoginThrottle(db, 3, 20, time.Minute)
+	client := seedClient(t, db, "client@test.fr")
+	owner := seedClient(t, db, "owner@test.fr")
+	owner.Role = models.RoleOwner
+	for i := 0; i < 3; i++ {
+		if err := lt.RecordFailure(loginRequest("10.0.0.1"), "client@test.fr"); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+
+	for _, l := range []models.LimiteConnexion{
+		limite(t, db, models.LimiteParEmail, "client@test.fr"),
+		limite(t, db, models.LimiteParIP, "10.0.0.1"),
+	} {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/lockouts/"+l.ID, nil)
+		req = req.WithContext(WithStaff(req.Context(), owner))
+		rec := httptest.NewRecorder()
+		lt.ClearLockoutHandler(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("levée du compteur %s: statut %d, attendu %d", l.Kind, rec.Code, http.StatusNoContent)
+		}
+		var entry models.JournalAudit
+		if err := db.Where("entity_type = ? AND entity_id = ?", AuditLockout, l.ID).First(&entry).Error; err != nil {
+			t.Fatalf("entrée d'audit du compteur %s: %v", l.Kind, err)
+		}
+		changes := string(entry.Changes)
+		if strings.Contains(changes, "client@test.fr") {
+			t.Errorf("compteur %s: l'email figure dans le journal: %s", l.Kind, changes)
+		}
+		want := `"client_id":{"before":"` + client.ID + `"`
+		if l.Kind == models.LimiteParIP {
+			want = `"value":{"before":"10.0.0.1"`
+		}
+		if !strings.Contains(changes, want) {
+			t.Errorf("compteur %s: %s, attendu %s", l.Kind, changes, want)
+		}
+	}
+}
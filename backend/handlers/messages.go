@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/messaging"
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Natures des messages envoyés par email ou SMS (enregistrées dans la file d'envoi)
+const (
+	MessageReservationConfirmee = "reservation_confirmee"
+	MessageReservationRappel    = "reservation_rappel"
+	MessageRecuCommande         = "recu_commande"
+)
+
+// clientLang renvoie la langue préférée du client (langue par défaut si inconnue).
+func clientLang(tx *gorm.DB, clientID string) (string, error) {
+	if clientID == "" {
+		return i18n.DefaultLang, nil
+	}
+	var client models.Client
+	err := tx.Select("langue").First(&client, "id = ?", clientID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	return i18n.Resolve(client.Langue), nil
+}
+
+// reservationLang renvoie la langue des messages d'une réservation: celle choisie à la réservation,
+// sinon celle du client lié.
+func reservationLang(tx *gorm.DB, reservation models.Reservation) (string, error) {
+	if lang := i18n.Normalize(reservation.Langue); lang != "" {
+		return lang, nil
+	}
+	return clientLang(tx, reservation.ClientID)
+}
+
+// reservationVars renvoie les variables des modèles de messages de réservation.
+func reservationVars(reservation models.Reservation, lang string) map[string]interface{} {
+	return map[string]interface{}{
+		"Nom":      reservation.ClientName,
+		"Date":     i18n.FormatDate(reservation.ReservationDate, lang),
+		"Convives": reservation.NumGuests,
+	}
+}
+
+// enqueueTemplate met en file un message rendu à partir d'un modèle du catalogue.
+func enqueueTemplate(tx *gorm.DB, channel, to, key, lang, kind, referenceID string, vars map[string]interface{}) error {
+	if to == "" {
+		return nil
+	}
+	subject, body, err := i18n.Render(tx, key, lang, vars)
+	if err != nil {
+		return err
+	}
+	return messaging.Enqueue(tx, messaging.Message{
+		Channel:     channel,
+		To:          to,
+		Subject:     subject,
+		Body:        body,
+		Kind:        kind,
+		ReferenceID: referenceID,
+	})
+}
+
+// enqueueReservationConfirmation envoie la confirmation de réservation par email et par SMS
+// aux coordonnées saisies sur la réservation.
+func enqueueReservationConfirmation(tx *gorm.DB, reservation models.Reservation, lang string) error {
+	vars := reservationVars(reservation, lang)
+	if err := enqueueTemplate(tx, messaging.ChannelEmail, reservation.ClientEmail, i18n.EmailReservationConfirmee,
+		lang, MessageReservationConfirmee, reservation.ID, vars); err != nil {
+		return err
+	}
+	return enqueueTemplate(tx, messaging.ChannelSMS, reservation.ClientPhone, i18n.SMSReservationConfirmee,
+		lang, MessageReservationConfirmee, reservation.ID, vars)
+}
+
+// enqueueReservationReminder envoie le rappel de réservation par email.
+func enqueueReservationReminder(tx *gorm.DB, reservation models.Reservation, lang string) error {
+	return enqueueTemplate(tx, messaging.ChannelEmail, reservation.ClientEmail, i18n.EmailReservationRappel,
+		lang, MessageReservationRappel, reservation.ID, reservationVars(reservation, lang))
+}
+
+// enqueueOrderReceipt envoie le reçu d'un paiement encaissé à l'email du client.
+func enqueueOrderReceipt(tx *gorm.DB, commande *models.Commande, paiement *models.Paiement) error {
+	var client models.Client
+	if err := tx.Select("email", "prenom_client", "langue").First(&client, "id = ?", commande.ClientID).Error; err != nil {
+		return err
+	}
+	lignes := commande.Lignes
+	if len(lignes) == 0 {
+		if err := tx.Where("commande_id = ?", commande.ID).Find(&lignes).Error; err != nil {
+			return err
+		}
+	}
+
+	vars := map[string]interface{}{
+		"Nom":             client.PrenomClient,
+		"Commande":        shortID(commande.ID),
+		"Total":           fmt.Sprintf("%.2f", commande.TotalAmount),
+		"MethodePaiement": paiement.Method,
+		"MontantPaye":     fmt.Sprintf("%.2f", paiement.Amount),
+	}
+	items := make([]map[string]interface{}, 0, len(lignes))
+	for _, l := range lignes {
+		items = append(items, map[string]interface{}{
+			"Quantite": l.Quantity,
+			"Plat":     l.PlatName,
+			"Montant":  fmt.Sprintf("%.2f", l.Montant()),
+		})
+	}
+	vars["Lignes"] = items
+
+	return enqueueTemplate(tx, messaging.ChannelEmail, client.Email, i18n.EmailRecuCommande,
+		i18n.Resolve(client.Langue), MessageRecuCommande, commande.ID, vars)
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Pagination par défaut de la liste des notifications
+const (
+	notificationsPageSize    = 20
+	notificationsMaxPageSize = 100
+)
+
+// NotificationHandler regroupe les dépendances et les méthodes de la boîte de notifications du client.
+type NotificationHandler struct {
+	DB *gorm.DB
+}
+
+// NewNotificationHandler crée une nouvelle instance de NotificationHandler.
+func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
+	return &NotificationHandler{DB: db}
+}
+
+// NotificationPage est une page de notifications avec le nombre de non lues (badge de l'application).
+type NotificationPage struct {
+	Notifications []models.Notification `json:"notifications"`
+	Page          int                   `json:"page"`
+	PageSize      int                   `json:"page_size"`
+	Total         int64                 `json:"total"`
+	UnreadCount   int64                 `json:"unread_count"`
+}
+
+// CreateNotification ajoute une notification dans la boîte du client.
+// Ne fait rien si aucun client n'est lié (ex: réservation faite sans compte).
+func CreateNotification(db *gorm.DB, clientID, notifType, referenceID, message string) error {
+	if clientID == "" {
+		return nil
+	}
+	return db.Create(&models.Notification{
+		ClientID:    clientID,
+		Type:        notifType,
+		ReferenceID: referenceID,
+		Message:     message,
+	}).Error
+}
+
+// shortID renvoie le début d'un UUID, plus lisible dans un message.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// renderNotification produit le texte d'une notification à partir du catalogue de messages.
+func renderNotification(tx *gorm.DB, key, lang string, vars map[string]interface{}) (string, error) {
+	_, body, err := i18n.Render(tx, key, lang, vars)
+	return body, err
+}
+
+// notifyCommandeStatus prévient le client du nouveau statut de sa commande, dans sa langue.
+func notifyCommandeStatus(tx *gorm.DB, commande *models.Commande) error {
+	lang, err := clientLang(tx, commande.ClientID)
+	if err != nil {
+		return err
+	}
+	message, err := renderNotification(tx, i18n.NotifCommandeStatut, lang, map[string]interface{}{
+		"Commande": shortID(commande.ID),
+		"Statut":   i18n.StatusLabel(commande.Status, lang),
+	})
+	if err != nil {
+		return err
+	}
+	return CreateNotification(tx, commande.ClientID, models.NotificationCommande, commande.ID, message)
+}
+
+// NotifyReservationStatus prévient le client de la confirmation ou de l'annulation de sa réservation.
+// La confirmation est aussi envoyée par email et SMS. Les autres statuts ne génèrent pas de notification.
+func NotifyReservationStatus(db *gorm.DB, reservation models.Reservation) error {
+	var key string
+	switch reservation.Status {
+	case "Confirmée":
+		key = i18n.NotifReservationConfirmee
+	case "Annulée":
+		key = i18n.NotifReservationAnnulee
+	default:
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		lang, err := reservationLang(tx, reservation)
+		if err != nil {
+			return err
+		}
+		message, err := renderNotification(tx, key, lang, reservationVars(reservation, lang))
+		if err != nil {
+			return err
+		}
+		if err := CreateNotification(tx, reservation.ClientID, models.NotificationReservation, reservation.ID, message); err != nil {
+			return err
+		}
+		if reservation.Status == "Confirmée" {
+			return enqueueReservationConfirmation(tx, reservation, lang)
+		}
+		return nil
+	})
+}
+
+// countUnread renvoie le nombre de notifications non lues du client.
+func (nh *NotificationHandler) countUnread(clientID string) (int64, error) {
+	var count int64
+	err := nh.DB.Model(&models.Notification{}).Where("client_id = ? AND is_read = ?", clientID, false).Count(&count).Error
+	return count, err
+}
+
+// queryInt lit un paramètre entier positif de la requête (valeur par défaut sinon).
+func queryInt(r *http.Request, name string, def int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
+
+// ListHandler renvoie les notifications du client, les plus récentes en premier.
+// Méthode: GET /api/notifications?page=1&page_size=20&unread=true
+func (nh *NotificationHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	page := NotificationPage{
+		Page:     queryInt(r, "page", 1),
+		PageSize: queryInt(r, "page_size", notificationsPageSize),
+	}
+	if page.PageSize > notificationsMaxPageSize {
+		page.PageSize = notificationsMaxPageSize
+	}
+
+	query := nh.DB.Model(&models.Notification{}).Where("client_id = ?", clientID)
+	if r.URL.Query().Get("unread") == "true" {
+		query = query.Where("is_read = ?", false)
+	}
+	if err := query.Count(&page.Total).Error; err != nil {
+		log.Printf("Erreur DB lors du comptage des notifications (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	if err := query.Order("created_at DESC").
+		Limit(page.PageSize).Offset((page.Page - 1) * page.PageSize).
+		Find(&page.Notifications).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération des notifications (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	unread, err := nh.countUnread(clientID)
+	if err != nil {
+		log.Printf("Erreur DB lors du comptage des notifications non lues (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	page.UnreadCount = unread
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// UnreadCountHandler renvoie seulement le nombre de notifications non lues.
+// Méthode: GET /api/notifications/unread-count
+func (nh *NotificationHandler) UnreadCountHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	unread, err := nh.countUnread(clientID)
+	if err != nil {
+		log.Printf("Erreur DB lors du comptage des notifications non lues (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]int64{"unread_count": unread})
+}
+
+// MarkAllReadHandler marque toutes les notifications du client comme lues.
+// Méthode: PUT /api/notifications/read-all
+func (nh *NotificationHandler) MarkAllReadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	result := nh.DB.Model(&models.Notification{}).
+		Where("client_id = ? AND is_read = ?", clientID, false).
+		Update("is_read", true)
+	if result.Error != nil {
+		log.Printf("Erreur DB lors du marquage des notifications (client: %s): %v", clientID, result.Error)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]int64{"updated": result.RowsAffected, "unread_count": 0})
+}
+
+// ItemHandler gère une notification du client:
+// PUT /api/notifications/{id}/read pour la marquer lue, DELETE /api/notifications/{id} pour la supprimer.
+func (nh *NotificationHandler) ItemHandler(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	// Attendu: ["", "api", "notifications", id] ou ["", "api", "notifications", id, "read"]
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[3] == "" || len(parts) > 5 || (len(parts) == 5 && parts[4] != "read") {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	id := parts[3]
+	markRead := len(parts) == 5
+
+	switch {
+	case markRead && r.Method == http.MethodPut, !markRead && r.Method == http.MethodDelete:
+	default:
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	// Le filtre sur client_id empêche d'accéder aux notifications d'un autre client
+	var notification models.Notification
+	if err := nh.DB.Where("id = ? AND client_id = ?", id, clientID).First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusNotFound, ErrNotificationNotFound)
+			return
+		}
+		log.Printf("Erreur DB lors de la récupération de la notification (ID: %s): %v", id, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	if !markRead {
+		if err := nh.DB.Delete(&notification).Error; err != nil {
+			log.Printf("Erreur DB lors de la suppression de la notification (ID: %s): %v", id, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if !notification.IsRead {
+		notification.IsRead = true
+		if err := nh.DB.Model(&notification).Update("is_read", true).Error; err != nil {
+			log.Printf("Erreur DB lors du marquage de la notification (ID: %s): %v", id, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+			return
+		}
+	}
+	respondWithJSON(w, http.StatusOK, notification)
+}
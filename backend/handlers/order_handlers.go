@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// errPanierVide est renvoyée quand un client tente de commander un panier vide.
+var errPanierVide = errors.New("panier vide")
+
+// OrderHandler regroupe les dépendances et les méthodes pour les commandes.
+type OrderHandler struct {
+	DB     *gorm.DB
+	Events *EventBroker // Diffusion des nouvelles commandes et changements de statut
+}
+
+// NewOrderHandler crée une nouvelle instance de OrderHandler.
+func NewOrderHandler(db *gorm.DB, events *EventBroker) *OrderHandler {
+	return &OrderHandler{DB: db, Events: events}
+}
+
+// CheckoutHandler transforme le panier du client en commande, dans une seule transaction.
+// Le total est calculé côté serveur à partir du prix actuel des plats, puis le panier est vidé.
+// Méthode: POST /api/cart/checkout
+func (oh *OrderHandler) CheckoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+	// Commander demande une adresse email confirmée
+	if !requireVerifiedEmail(w, r, oh.DB, clientID) {
+		return
+	}
+
+	var commande models.Commande
+	err := oh.DB.Transaction(func(tx *gorm.DB) error {
+		panier, err := getOrCreatePanier(tx, clientID)
+		if err != nil {
+			return err
+		}
+		lignes, plats, err := loadPanierLines(tx, panier.ID)
+		if err != nil {
+			return err
+		}
+
+		commande = models.Commande{
+			ClientID:  clientID,
+			OrderDate: time.Now(),
+			Status:    models.CommandeEnAttente,
+		}
+		for _, l := range lignes {
+			plat, ok := plats[l.PlatID]
+			if !ok || l.Quantity <= 0 {
+				continue // Plat retiré du menu depuis l'ajout au panier
+			}
+			ligne := models.CommandePlat{
+				PlatID:       plat.ID,
+				Quantity:     l.Quantity,
+				UnitPrice:    plat.Price,
+				PlatName:     plat.Name,
+				PlatCategory: plat.Category,
+			}
+			commande.Lignes = append(commande.Lignes, ligne)
+			commande.TotalAmount += roundMontant(ligne.Montant())
+		}
+		if len(commande.Lignes) == 0 {
+			return errPanierVide
+		}
+		commande.TotalAmount = roundMontant(commande.TotalAmount)
+
+		// Crée la commande et ses lignes (GORM insère l'association Lignes)
+		if err := tx.Create(&commande).Error; err != nil {
+			return err
+		}
+		if err := recordCommandeCreation(tx, &commande, ActorFromContext(r.Context())); err != nil {
+			return err
+		}
+		return tx.Where("panier_id = ?", panier.ID).Delete(&models.PanierPlat{}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errPanierVide) {
+			respondWithError(w, r, http.StatusBadRequest, ErrCartEmpty)
+			return
+		}
+		log.Printf("Erreur DB lors de la validation du panier (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	oh.Events.Publish(EventOrderCreated, commande.ClientID, commande)
+	respondWithJSON(w, http.StatusCreated, commande)
+	log.Printf("Commande créée depuis le panier (client: %s, ID: %s, total: %.2f)", clientID, commande.ID, commande.TotalAmount)
+}
+
+// ListMyOrdersHandler liste les commandes du client authentifié, les plus récentes en premier.
+// Méthode: GET /api/orders
+func (oh *OrderHandler) ListMyOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	var commandes []models.Commande
+	if err := oh.DB.Preload("Lignes").Where("client_id = ?", clientID).Order("order_date DESC").Find(&commandes).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération des commandes (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, commandes)
+}
+
+// findMyOrder charge une commande du client authentifié à partir de l'ID présent dans l'URL
+// (/api/orders/{id}...). Elle répond elle-même en cas d'erreur et renvoie false.
+func (oh *OrderHandler) findMyOrder(w http.ResponseWriter, r *http.Request) (models.Commande, bool) {
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return models.Commande{}, false
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 4 || parts[3] == "" { // Attendu: /api/orders/{id}
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return models.Commande{}, false
+	}
+	commandeID := parts[3]
+
+	commande, err := loadCommande(oh.DB, commandeID)
+	if err == nil && commande.ClientID != clientID {
+		err = gorm.ErrRecordNotFound // Ne révèle pas l'existence des commandes des autres clients
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusNotFound, ErrOrderNotFound)
+			return models.Commande{}, false
+		}
+		log.Printf("Erreur DB lors de la récupération de la commande (ID: %s): %v", commandeID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return models.Commande{}, false
+	}
+	return commande, true
+}
+
+// GetMyOrderHandler renvoie une commande du client avec son statut actuel et son historique.
+// Méthode: GET /api/orders/{id}
+func (oh *OrderHandler) GetMyOrderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	commande, ok := oh.findMyOrder(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, commande)
+}
+
+// ReorderHandler ajoute au panier les plats d'une commande passée (toujours au menu),
+// puis renvoie le panier mis à jour au prix actuel.
+// Méthode: POST /api/orders/{id}/reorder
+func (oh *OrderHandler) ReorderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	commande, ok := oh.findMyOrder(w, r)
+	if !ok {
+		return
+	}
+
+	var view CartView
+	err := oh.DB.Transaction(func(tx *gorm.DB) error {
+		panier, err := getOrCreatePanier(tx, commande.ClientID)
+		if err != nil {
+			return err
+		}
+		for _, l := range commande.Lignes {
+			var count int64
+			if err := tx.Model(&models.Plat{}).Where("id = ?", l.PlatID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				continue // Plat retiré du menu depuis la commande
+			}
+
+			var ligne models.PanierPlat
+			err := tx.Where("panier_id = ? AND plat_id = ?", panier.ID, l.PlatID).First(&ligne).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ligne = models.PanierPlat{PanierID: panier.ID, PlatID: l.PlatID, Quantity: l.Quantity}
+				err = tx.Create(&ligne).Error
+			} else if err == nil {
+				ligne.Quantity += l.Quantity
+				err = tx.Save(&ligne).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		view, err = buildCartView(tx, panier)
+		return err
+	})
+	if err != nil {
+		log.Printf("Erreur DB lors de la recommande (commande: %s): %v", commande.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, view)
+	log.Printf("Commande %s ajoutée à nouveau au panier (client: %s)", commande.ID, commande.ClientID)
+}
+
+// AdminListOrdersHandler liste toutes les commandes, avec un filtre optionnel par statut.
+// Méthode: GET /admin/orders?status={statut}
+func (oh *OrderHandler) AdminListOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	statusFilter := r.URL.Query().Get("status")
+	query := oh.DB.Preload("Lignes").Order("order_date DESC")
+	if statusFilter != "" && statusFilter != "Tous" {
+		if !models.StatutCommandeValide(statusFilter) {
+			respondWithError(w, r, http.StatusBadRequest, ErrOrderStatusInvalid)
+			return
+		}
+		query = query.Where("status = ?", statusFilter)
+	}
+
+	var commandes []models.Commande
+	if err := query.Find(&commandes).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération des commandes (filtre: %s): %v", statusFilter, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, commandes)
+}
+
+// AdminGetOrderHandler renvoie une commande avec ses lignes et l'historique de ses statuts.
+// Méthode: GET /admin/orders/{id}
+func (oh *OrderHandler) AdminGetOrderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 4 || parts[3] == "" { // Attendu: /admin/orders/{id}
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	commandeID := parts[3]
+
+	commande, err := loadCommande(oh.DB, commandeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusNotFound, ErrOrderNotFound)
+			return
+		}
+		log.Printf("Erreur DB lors de la récupération de la commande (ID: %s): %v", commandeID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, commande)
+}
+
+// AdminUpdateOrderStatusHandler fait passer une commande à un nouveau statut.
+// Sans "status" dans le corps, la commande passe à l'étape normale suivante.
+// Méthode: PUT /admin/orders/{id}/status
+func (oh *OrderHandler) AdminUpdateOrderStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 || parts[3] == "" { // Attendu: /admin/orders/{id}/status
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	commandeID := parts[3]
+
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+			return
+		}
+	}
+	if req.Status != "" && !models.StatutCommandeValide(req.Status) {
+		respondWithError(w, r, http.StatusBadRequest, ErrOrderStatusInvalid)
+		return
+	}
+
+	var commande models.Commande
+	err := oh.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if commande, err = loadCommande(tx, commandeID); err != nil {
+			return err
+		}
+		from, to := commande.Status, req.Status
+		if to == "" {
+			to = models.StatutCommandeSuivant(commande.Status)
+		}
+		if err := changeCommandeStatus(tx, &commande, to, ActorFromContext(r.Context()), req.Note); err != nil {
+			return err
+		}
+		after := map[string]interface{}{"status": to}
+		if req.Note != "" {
+			after["note"] = req.Note
+		}
+		return RecordAudit(tx, r, AuditStatusChange, AuditCommande, commande.ID, map[string]interface{}{"status": from}, after)
+	})
+	if err != nil {
+		var transitionErr *TransitionError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondWithError(w, r, http.StatusNotFound, ErrOrderNotFound)
+		case errors.As(err, &transitionErr) && transitionErr.To == "":
+			respondWithErrorParams(w, r, http.StatusConflict, ErrOrderStatusFinal, map[string]string{"from": transitionErr.From})
+		case errors.As(err, &transitionErr):
+			respondWithErrorParams(w, r, http.StatusConflict, ErrOrderTransitionNotAllowed,
+				map[string]string{"from": transitionErr.From, "to": transitionErr.To})
+		default:
+			log.Printf("Erreur DB lors du changement de statut de la commande (ID: %s): %v", commandeID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		}
+		return
+	}
+
+	oh.Events.Publish(EventOrderStatusChanged, commande.ClientID, commande)
+	respondWithJSON(w, http.StatusOK, commande)
+	log.Printf("Commande %s passée au statut '%s'", commande.ID, commande.Status)
+}
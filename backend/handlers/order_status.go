@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// TransitionError est renvoyée quand un changement de statut de commande n'est pas autorisé.
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("transition de statut interdite: '%s' -> '%s'", e.From, e.To)
+}
+
+// changeCommandeStatus fait passer la commande au statut "to" en respectant le cycle de vie,
+// enregistre le changement dans l'historique et prévient le client. Doit être appelée dans une transaction.
+func changeCommandeStatus(tx *gorm.DB, commande *models.Commande, to string, actor Actor, note string) error {
+	if !models.TransitionCommandeAutorisee(commande.Status, to) {
+		return &TransitionError{From: commande.Status, To: to}
+	}
+
+	entry := models.CommandeStatutHistorique{
+		CommandeID: commande.ID,
+		FromStatus: commande.Status,
+		ToStatus:   to,
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		Note:       note,
+	}
+	if err := tx.Model(commande).Update("status", to).Error; err != nil {
+		return err
+	}
+	commande.Status = to
+	if err := tx.Create(&entry).Error; err != nil {
+		return err
+	}
+	commande.Historique = append(commande.Historique, entry)
+	return notifyCommandeStatus(tx, commande)
+}
+
+// recordCommandeCreation enregistre le statut initial d'une nouvelle commande dans l'historique.
+func recordCommandeCreation(tx *gorm.DB, commande *models.Commande, actor Actor) error {
+	entry := models.CommandeStatutHistorique{
+		CommandeID: commande.ID,
+		ToStatus:   commande.Status,
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return err
+	}
+	commande.Historique = append(commande.Historique, entry)
+	return nil
+}
+
+// loadCommande charge une commande avec ses lignes et son historique (ordre chronologique).
+func loadCommande(db *gorm.DB, id string) (models.Commande, error) {
+	var commande models.Commande
+	err := db.Preload("Lignes").
+		Preload("Historique", func(tx *gorm.DB) *gorm.DB { return tx.Order("created_at ASC") }).
+		First(&commande, "id = ?", id).Error
+	return commande, err
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/messaging"
+	"restaurant-app/backend/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// MessageReinitialisationMDP est la nature des emails de réinitialisation dans la file d'envoi.
+const MessageReinitialisationMDP = "reinitialisation_mot_de_passe"
+
+// errJetonInvalide est renvoyée pour un jeton de réinitialisation inconnu, utilisé ou expiré.
+var errJetonInvalide = errors.New("jeton de réinitialisation invalide ou expiré")
+
+// PasswordHandler gère l'oubli du mot de passe: envoi d'un jeton par email, puis choix d'un nouveau mot de passe.
+type PasswordHandler struct {
+	DB       *gorm.DB
+	Throttle *LoginThrottle // Limite les demandes de réinitialisation par email et par adresse IP
+	TTL      time.Duration  // Durée de validité d'un jeton de réinitialisation
+	AppURL   string         // Adresse de l'application, pour le lien envoyé par email
+
+	dispatch func(func()) // Lance l'envoi du lien hors de la requête (goroutine; synchrone dans les tests)
+}
+
+// NewPasswordHandler crée une nouvelle instance de PasswordHandler.
+func NewPasswordHandler(db *gorm.DB, throttle *LoginThrottle, ttl time.Duration, appURL string) *PasswordHandler {
+	return &PasswordHandler{
+		DB:       db,
+		Throttle: throttle,
+		TTL:      ttl,
+		AppURL:   strings.TrimSuffix(appURL, "/"),
+		dispatch: func(f func()) { go f() },
+	}
+}
+
+// resetLink renvoie le lien de réinitialisation envoyé au client.
+func (ph *PasswordHandler) resetLink(token string) string {
+	return ph.AppURL + "/reinitialisation?token=" + url.QueryEscape(token)
+}
+
+// invalidateResetTokens marque comme utilisés les jetons encore valides d'un client.
+func invalidateResetTokens(tx *gorm.DB, clientID string) error {
+	return tx.Model(&models.JetonReinitialisation{}).
+		Where("client_id = ? AND used_at IS NULL", clientID).
+		Update("used_at", time.Now()).Error
+}
+
+// RevokeCredentials invalide les jetons de réinitialisation et révoque toutes les sessions d'un client
+// dont le mot de passe vient d'être remplacé. Doit être appelée dans la transaction du changement.
+func RevokeCredentials(tx *gorm.DB, clientID string) error {
+	if err := invalidateResetTokens(tx, clientID); err != nil {
+		return err
+	}
+	return revokeClientSessions(tx, clientID)
+}
+
+// issueResetToken crée un jeton pour le client (les précédents sont invalidés) et l'envoie par email.
+func (ph *PasswordHandler) issueResetToken(client models.Client, ip string) error {
+	token, err := newToken("rst_")
+	if err != nil {
+		return err
+	}
+	return ph.DB.Transaction(func(tx *gorm.DB) error {
+		if err := invalidateResetTokens(tx, client.ID); err != nil {
+			return err
+		}
+		jeton := models.JetonReinitialisation{
+			ClientID:  client.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: time.Now().Add(ph.TTL),
+			IP:        ip,
+		}
+		if err := tx.Create(&jeton).Error; err != nil {
+			return err
+		}
+		lang := i18n.Resolve(client.Langue)
+		return enqueueTemplate(tx, messaging.ChannelEmail, client.Email, i18n.EmailReinitialisationMDP,
+			lang, MessageReinitialisationMDP, jeton.ID, map[string]interface{}{
+				"Nom":        client.PrenomClient,
+				"Lien":       ph.resetLink(token),
+				"Code":       token,
+				"Expiration": i18n.FormatDate(jeton.ExpiresAt, lang),
+			})
+	})
+}
+
+// sendResetLink envoie un lien de réinitialisation si l'email correspond à un compte.
+// Elle est appelée hors de la requête: les erreurs sont seulement journalisées.
+func (ph *PasswordHandler) sendResetLink(email, ip string) {
+	var client models.Client
+	err := ph.DB.Where("email = ?", email).First(&client).Error
+	switch {
+	case err == nil:
+		if err := ph.issueResetToken(client, ip); err != nil {
+			log.Printf("Erreur lors de l'envoi du lien de réinitialisation (client: %s): %v", client.ID, err)
+			return
+		}
+		log.Printf("Lien de réinitialisation du mot de passe envoyé (client: %s, IP: %s)", client.ID, ip)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		log.Printf("Demande de réinitialisation pour un email inconnu (IP: %s)", ip)
+	default:
+		log.Printf("Erreur DB lors de la demande de réinitialisation: %v", err)
+	}
+}
+
+// ForgotPasswordHandler envoie un lien de réinitialisation à l'email indiqué s'il correspond à un compte.
+// La réponse est la même que l'email soit inscrit ou non, pour ne pas révéler les comptes existants:
+// les demandes sont limitées par email et par adresse IP quel que soit l'email, et la recherche
+// du compte comme l'envoi du lien se font après la réponse, pour que sa durée ne dépende pas du compte.
+// Méthode: POST /auth/forgot-password {"email": ...}
+func (ph *PasswordHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("email", FieldRequired))
+		return
+	}
+
+	wait, err := ph.Throttle.ThrottleReset(r, req.Email)
+	if err != nil {
+		log.Printf("Erreur DB lors de la limitation des demandes de réinitialisation: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	if wait > 0 {
+		RespondThrottled(w, r, wait)
+		return
+	}
+
+	email, ip := req.Email, clientIP(r)
+	ph.dispatch(func() { ph.sendResetLink(email, ip) })
+	respondWithJSON(w, http.StatusAccepted, map[string]string{"status": "accepted"})
+}
+
+// ResetPasswordHandler remplace le mot de passe à l'aide d'un jeton de réinitialisation.
+// Le jeton ne sert qu'une fois; toutes les sessions ouvertes du client sont révoquées.
+// Méthode: POST /auth/reset-password {"token": ..., "motDePasse": ...}
+func (ph *PasswordHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Token      string `json:"token"`
+		MotDePasse string `json:"motDePasse"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	var details []FieldError
+	if strings.TrimSpace(req.Token) == "" {
+		details = append(details, Field("token", FieldRequired))
+	}
+	if req.MotDePasse == "" {
+		details = append(details, Field("motDePasse", FieldRequired))
+	}
+	if len(details) > 0 {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, details...)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.MotDePasse), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Erreur lors du hachage du nouveau mot de passe: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	var jeton models.JetonReinitialisation
+	err = ph.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", hashToken(strings.TrimSpace(req.Token))).First(&jeton).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errJetonInvalide
+			}
+			return err
+		}
+		if jeton.UsedAt != nil || time.Now().After(jeton.ExpiresAt) {
+			return errJetonInvalide
+		}
+		// Marque le jeton utilisé sous condition, pour qu'une requête concurrente ne puisse pas le réutiliser
+		result := tx.Model(&models.JetonReinitialisation{}).
+			Where("id = ? AND used_at IS NULL", jeton.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errJetonInvalide
+		}
+		if err := tx.Model(&models.Client{}).Where("id = ?", jeton.ClientID).
+			Update("mot_de_passe_hashed", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return RevokeCredentials(tx, jeton.ClientID)
+	})
+	if err != nil {
+		if errors.Is(err, errJetonInvalide) {
+			respondWithError(w, r, http.StatusBadRequest, ErrResetTokenInvalid, Field("token", FieldInvalid))
+			return
+		}
+		log.Printf("Erreur DB lors de la réinitialisation du mot de passe: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("Mot de passe réinitialisé (client: %s, IP: %s)", jeton.ClientID, clientIP(r))
+}
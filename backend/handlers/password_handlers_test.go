@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// newTestPasswordHandler renvoie un PasswordHandler qui envoie le lien avant de répondre, pour les tests.
+func newTestPasswordHandler(db *gorm.DB) *PasswordHandler {
+	ph := NewPasswordHandler(db, NewLoginThrottle(db, 5, 20, 15*time.Minute), time.Hour, "http://app.test")
+	ph.dispatch = func(f func()) { f() }
+	return ph
+}
+
+// forgotPassword envoie une demande de réinitialisation pour l'email depuis l'adresse IP donnée.
+func forgotPassword(ph *PasswordHandler, email, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/forgot-password", strings.NewReader(`{"email":"`+email+`"}`))
+	req.RemoteAddr = ip + ":40000"
+	rec := httptest.NewRecorder()
+	ph.ForgotPasswordHandler(rec, req)
+	return rec
+}
+
+func TestForgotPasswordHandlerSameAnswerForUnknownEmail(t *testing.T) {
+	db := newTestDB(t)
+	ph := newTestPasswordHandler(db)
+	client := seedClient(t, db, "inscrit@test.fr")
+
+	// Même suite de réponses pour un compte existant et pour un email inconnu:
+	// deux demandes sans attente, puis une attente avant la suivante
+	for _, tt := range []struct{ email, ip string }{
+		{"inscrit@test.fr", "10.0.0.1"},
+		{"inconnu@test.fr", "10.0.0.2"},
+	} {
+		var got []int
+		for i := 0; i < 3; i++ {
+			got = append(got, forgotPassword(ph, tt.email, tt.ip).Code)
+		}
+		want := []int{http.StatusAccepted, http.StatusAccepted, http.StatusTooManyRequests}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: statuts %v, attendu %v", tt.email, got, want)
+			}
+		}
+	}
+
+	var jetons []models.JetonReinitialisation
+	db.Find(&jetons)
+	if len(jetons) != 2 {
+		t.Fatalf("%d jetons créés, attendu 2 (un par demande acceptée pour le compte existant)", len(jetons))
+	}
+	for _, j := range jetons {
+		if j.ClientID != client.ID || j.IP != "10.0.0.1" {
+			t.Errorf("jeton %+v: attendu client %s depuis 10.0.0.1", j, client.ID)
+		}
+	}
+	var sent, unknown int64
+	db.Model(&models.MessageSortant{}).Where("recipient = ?", "inscrit@test.fr").Count(&sent)
+	db.Model(&models.MessageSortant{}).Where("recipient = ?", "inconnu@test.fr").Count(&unknown)
+	if sent != 2 || unknown != 0 {
+		t.Errorf("%d emails au compte existant et %d à l'adresse inconnue, attendu 2 et 0", sent, unknown)
+	}
+}
+
+func TestForgotPasswordHandlerLocksEmail(t *testing.T) {
+	db := newTestDB(t)
+	ph := newTestPasswordHandler(db)
+	skipWait := func() {
+		db.Model(&models.LimiteConnexion{}).Where("1 = 1").Update("blocked_until", time.Now().Add(-time.Second))
+	}
+
+	for i := 0; i < resetMaxRequests; i++ {
+		skipWait()
+		if code := forgotPassword(ph, "Client@Test.fr ", "10.0.0.1").Code; code != http.StatusAccepted {
+			t.Fatalf("demande %d: statut %d, attendu %d", i+1, code, http.StatusAccepted)
+		}
+	}
+	// Verrouillage pour toute la durée, quelle que soit la casse de l'email ou l'adresse IP
+	rec := forgotPassword(ph, "client@test.fr", "10.0.0.2")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("statut %d après %d demandes, attendu %d", rec.Code, resetMaxRequests, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "900" {
+		t.Errorf("Retry-After = %q, attendu la durée du verrouillage (900)", got)
+	}
+	var limite models.LimiteConnexion
+	if err := db.Where("kind = ? AND value = ?", models.LimiteReinitEmail, "client@test.fr").First(&limite).Error; err != nil {
+		t.Fatalf("compteur de l'email: %v", err)
+	}
+	if limite.LockedAt == nil || limite.Failures != resetMaxRequests {
+		t.Errorf("compteur %+v: attendu verrouillé après %d demandes", limite, resetMaxRequests)
+	}
+	// Les compteurs de connexion ne sont pas touchés
+	var login int64
+	db.Model(&models.LimiteConnexion{}).Where("kind IN ?", []string{models.LimiteParEmail, models.LimiteParIP}).Count(&login)
+	if login != 0 {
+		t.Errorf("%d compteurs de connexion créés par des demandes de réinitialisation", login)
+	}
+}
+
+func TestForgotPasswordHandlerThrottlesIP(t *testing.T) {
+	db := newTestDB(t)
+	ph := newTestPasswordHandler(db)
+
+	// Des emails différents depuis la même adresse: attente après les demandes tolérées
+	for i := 0; i <= resetIPFreeRequests; i++ {
+		email := "client" + string(rune('a'+i)) + "@test.fr"
+		if code := forgotPassword(ph, email, "10.0.0.9").Code; code != http.StatusAccepted {
+			t.Fatalf("demande %d: statut %d, attendu %d", i+1, code, http.StatusAccepted)
+		}
+	}
+	if code := forgotPassword(ph, "autre@test.fr", "10.0.0.9").Code; code != http.StatusTooManyRequests {
+		t.Fatalf("statut %d, attendu %d pour l'adresse IP", code, http.StatusTooManyRequests)
+	}
+	// Une autre adresse IP n'est pas concernée
+	if code := forgotPassword(ph, "autre@test.fr", "10.0.0.10").Code; code != http.StatusAccepted {
+		t.Errorf("statut %d depuis une autre adresse IP, attendu %d", code, http.StatusAccepted)
+	}
+}
+
+func TestRevokeCredentials(t *testing.T) {
+	db := newTestDB(t)
+	client := seedClient(t, db, "client@test.fr")
+	other := seedClient(t, db, "autre@test.fr")
+	for i, c := range []models.Client{client, other} {
+		id := strconv.Itoa(i)
+		db.Create(&models.SessionClient{ClientID: c.ID, AccessTokenHash: "at" + id, RefreshTokenHash: "rt" + id,
+			AccessExpiresAt: time.Now().Add(time.Hour), RefreshExpiresAt: time.Now().Add(24 * time.Hour)})
+		db.Create(&models.JetonReinitialisation{ClientID: c.ID, TokenHash: "rst" + id, ExpiresAt: time.Now().Add(time.Hour)})
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error { return RevokeCredentials(tx, client.ID) }); err != nil {
+		t.Fatalf("RevokeCredentials: %v", err)
+	}
+	for _, tt := range []struct {
+		clientID    string
+		wantRevoked int64
+	}{{client.ID, 1}, {other.ID, 0}} {
+		var sessions, jetons int64
+		db.Model(&models.SessionClient{}).Where("client_id = ? AND revoked_at IS NOT NULL", tt.clientID).Count(&sessions)
+		db.Model(&models.JetonReinitialisation{}).Where("client_id = ? AND used_at IS NOT NULL", tt.clientID).Count(&jetons)
+		if sessions != tt.wantRevoked || jetons != tt.wantRevoked {
+			t.Errorf("client %s: %d sessions révoquées et %d jetons invalidés, attendu %d",
+				tt.clientID, sessions, jetons, tt.wantRevoked)
+		}
+	}
+}
@@ -0,0 +1,598 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/models"
+	"restaurant-app/backend/payments"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// errDejaPaye est renvoyée quand la commande est déjà entièrement payée.
+	errDejaPaye = errors.New("commande déjà payée")
+	// errNonRemboursable est renvoyée quand le paiement n'a pas été encaissé ou est déjà remboursé.
+	errNonRemboursable = errors.New("paiement non remboursable")
+	// errMontantRemboursement est renvoyée quand le montant demandé dépasse ce qui reste remboursable.
+	errMontantRemboursement = errors.New("montant de remboursement invalide")
+	// errTropPercu est renvoyée quand l'encaissement d'un paiement dépasserait le total de la commande.
+	errTropPercu = errors.New("paiement supérieur au reste à payer")
+)
+
+// PaymentHandler regroupe les dépendances et les méthodes pour les paiements.
+type PaymentHandler struct {
+	DB      *gorm.DB
+	Events  *EventBroker
+	Gateway payments.Gateway // Prestataire utilisé pour les paiements par carte
+}
+
+// NewPaymentHandler crée une nouvelle instance de PaymentHandler.
+func NewPaymentHandler(db *gorm.DB, events *EventBroker, gateway payments.Gateway) *PaymentHandler {
+	return &PaymentHandler{DB: db, Events: events, Gateway: gateway}
+}
+
+// resteAPayer renvoie ce qu'il reste à payer sur la commande. AmountPaid étant net des remboursements,
+// le montant remboursé est aussi déduit: une commande remboursée, même en partie, reste soldée
+// et n'accepte pas de nouveau paiement pour la somme rendue.
+func resteAPayer(commande models.Commande) float64 {
+	return roundMontant(commande.TotalAmount - commande.AmountPaid - commande.AmountRefunded)
+}
+
+// markPaiementPaye enregistre l'encaissement d'un paiement, envoie le reçu au client
+// et confirme la commande encore en attente.
+// Un paiement qui porterait le montant payé au-delà du total de la commande est refusé (errTropPercu).
+// Renvoie true si le statut de la commande a changé. Doit être appelée dans une transaction.
+func markPaiementPaye(tx *gorm.DB, paiement *models.Paiement, commande *models.Commande, actor Actor) (bool, error) {
+	if roundMontant(paiement.Amount) > resteAPayer(*commande) {
+		return false, errTropPercu
+	}
+	paiement.Status = models.PaiementPaye
+	paiement.PaymentDate = time.Now()
+	if err := tx.Save(paiement).Error; err != nil {
+		return false, err
+	}
+	commande.AmountPaid = roundMontant(commande.AmountPaid + paiement.Amount)
+	if err := tx.Model(commande).Update("amount_paid", commande.AmountPaid).Error; err != nil {
+		return false, err
+	}
+	// Commande soldée: les autres paiements en attente ne peuvent plus être encaissés
+	if resteAPayer(*commande) <= 0 {
+		if err := tx.Model(&models.Paiement{}).
+			Where("commande_id = ? AND id <> ? AND status = ?", commande.ID, paiement.ID, models.PaiementEnAttente).
+			Update("status", models.PaiementRemplace).Error; err != nil {
+			return false, err
+		}
+	}
+	if err := enqueueOrderReceipt(tx, commande, paiement); err != nil {
+		return false, err
+	}
+	if commande.Status != models.CommandeEnAttente {
+		return false, nil
+	}
+	note := fmt.Sprintf("Paiement %s reçu (%.2f)", paiement.Method, paiement.Amount)
+	if err := changeCommandeStatus(tx, commande, models.CommandeConfirmee, actor, note); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// enregistrerTropPercu enregistre comme payé un paiement reçu au-delà du reste à payer (paiement par carte
+// remplacé mais réglé quand même): le montant payé de la commande dépasse alors son total jusqu'au
+// remboursement du paiement. Ni reçu ni changement de statut. Doit être appelée dans une transaction.
+func enregistrerTropPercu(tx *gorm.DB, paiement *models.Paiement, commande *models.Commande) error {
+	paiement.Status = models.PaiementPaye
+	paiement.PaymentDate = time.Now()
+	if err := tx.Save(paiement).Error; err != nil {
+		return err
+	}
+	commande.AmountPaid = roundMontant(commande.AmountPaid + paiement.Amount)
+	return tx.Model(commande).Update("amount_paid", commande.AmountPaid).Error
+}
+
+// PayOrderHandler démarre le paiement d'une commande du client.
+// "carte" passe par la passerelle et attend son rappel; "especes_livraison" et "comptoir"
+// créent un paiement à encaisser et confirment directement la commande.
+// Une commande n'a qu'un paiement en attente: un nouvel appel avec la même méthode reprend
+// le paiement en attente (200), une autre méthode le remplace (201).
+// Méthode: POST /api/orders/{id}/pay
+func (ph *PaymentHandler) PayOrderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 || parts[3] == "" { // Attendu: /api/orders/{id}/pay
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	commandeID := parts[3]
+
+	var req struct {
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	if !models.MethodePaiementValide(req.Method) {
+		respondWithError(w, r, http.StatusBadRequest, ErrPaymentMethodInvalid)
+		return
+	}
+
+	var paiement models.Paiement
+	var commande models.Commande
+	var intent payments.Intent
+	statusChanged, reused := false, false
+	err := ph.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if commande, err = loadCommande(tx, commandeID); err != nil {
+			return err
+		}
+		if commande.ClientID != clientID {
+			return gorm.ErrRecordNotFound
+		}
+		if commande.Status == models.CommandeAnnulee {
+			return &TransitionError{From: commande.Status, To: models.CommandeConfirmee}
+		}
+		reste := resteAPayer(commande)
+		if reste <= 0 {
+			return errDejaPaye
+		}
+
+		var pending []models.Paiement
+		if err := tx.Where("commande_id = ? AND status = ?", commande.ID, models.PaiementEnAttente).
+			Order("created_at DESC").Find(&pending).Error; err != nil {
+			return err
+		}
+		for _, p := range pending {
+			if !reused && p.Method == req.Method && roundMontant(p.Amount) == reste {
+				paiement, reused = p, true
+				continue
+			}
+			if err := tx.Model(&models.Paiement{}).Where("id = ?", p.ID).Update("status", models.PaiementRemplace).Error; err != nil {
+				return err
+			}
+		}
+		if reused {
+			intent = payments.Intent{ProviderRef: paiement.ProviderRef, CheckoutURL: paiement.CheckoutURL}
+			return nil
+		}
+
+		paiement = models.Paiement{
+			CommandeID: commande.ID,
+			Amount:     reste,
+			Method:     req.Method,
+			Status:     models.PaiementEnAttente,
+		}
+		if err := tx.Create(&paiement).Error; err != nil {
+			return err
+		}
+
+		if req.Method != models.PaiementCarte {
+			// Espèces ou comptoir: rien à attendre de la passerelle, la commande peut partir en cuisine
+			if commande.Status == models.CommandeEnAttente {
+				note := fmt.Sprintf("Paiement %s à encaisser", req.Method)
+				if err := changeCommandeStatus(tx, &commande, models.CommandeConfirmee, ActorFromContext(r.Context()), note); err != nil {
+					return err
+				}
+				statusChanged = true
+			}
+			return nil
+		}
+
+		intent, err = ph.Gateway.CreatePayment(payments.Request{
+			PaiementID:  paiement.ID,
+			CommandeID:  commande.ID,
+			Amount:      paiement.Amount,
+			Currency:    "EUR",
+			Description: fmt.Sprintf("Commande %s", commande.ID),
+		})
+		if err != nil {
+			return fmt.Errorf("passerelle %s: %w", ph.Gateway.Name(), err)
+		}
+		paiement.Provider = ph.Gateway.Name()
+		paiement.ProviderRef = intent.ProviderRef
+		paiement.CheckoutURL = intent.CheckoutURL
+		return tx.Save(&paiement).Error
+	})
+	if err != nil {
+		var transitionErr *TransitionError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondWithError(w, r, http.StatusNotFound, ErrOrderNotFound)
+		case errors.Is(err, errDejaPaye):
+			respondWithError(w, r, http.StatusConflict, ErrOrderAlreadyPaid)
+		case errors.As(err, &transitionErr):
+			respondWithError(w, r, http.StatusConflict, ErrOrderCancelled)
+		default:
+			log.Printf("Erreur lors de l'initiation du paiement (commande: %s): %v", commandeID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrPaymentGateway)
+		}
+		return
+	}
+
+	if statusChanged {
+		ph.Events.Publish(EventOrderStatusChanged, commande.ClientID, commande)
+	}
+	status := http.StatusCreated
+	if reused {
+		status = http.StatusOK
+	}
+	respondWithJSON(w, status, map[string]interface{}{
+		"paiement":     paiement,
+		"checkout_url": intent.CheckoutURL,
+	})
+	log.Printf("Paiement initié (commande: %s, méthode: %s, montant: %.2f, repris: %v)", commande.ID, paiement.Method, paiement.Amount, reused)
+}
+
+// applyWebhookEvent applique un événement de paiement par carte transmis par la passerelle.
+// Un paiement déjà traité n'est pas modifié. Renvoie la commande si son statut a changé, et la raison
+// pour laquelle l'événement a été ignoré (vide s'il a été appliqué). Doit être appelée dans une transaction.
+func (ph *PaymentHandler) applyWebhookEvent(tx *gorm.DB, event payments.WebhookEvent) (*models.Commande, string, error) {
+	if event.Type != payments.EventPaymentSucceeded && event.Type != payments.EventPaymentFailed {
+		return nil, "type d'événement non utilisé", nil
+	}
+
+	var paiement models.Paiement
+	err := tx.Where("provider = ? AND provider_ref = ?", ph.Gateway.Name(), event.ProviderRef).First(&paiement).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Le prestataire réessaierait sans fin une réponse en erreur: l'événement est gardé comme ignoré
+		return nil, "paiement inconnu", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if event.Type == payments.EventPaymentFailed {
+		if paiement.Status != models.PaiementEnAttente {
+			return nil, "paiement déjà traité", nil
+		}
+		paiement.Status = models.PaiementEchoue
+		return nil, "", tx.Save(&paiement).Error
+	}
+	// Un paiement remplacé a pu être réglé sur l'ancienne page de paiement: l'argent est reçu
+	if paiement.Status != models.PaiementEnAttente && paiement.Status != models.PaiementRemplace {
+		return nil, "paiement déjà traité", nil
+	}
+
+	commande, err := loadCommande(tx, paiement.CommandeID)
+	if err != nil {
+		return nil, "", err
+	}
+	changed, err := markPaiementPaye(tx, &paiement, &commande, Actor{Type: ActorSystem, ID: ph.Gateway.Name()})
+	if errors.Is(err, errTropPercu) {
+		// L'argent est reçu même si la commande est déjà payée: le paiement est enregistré
+		// pour qu'il compte dans le montant payé et puisse être remboursé (AdminRefundPaymentHandler)
+		if err := enregistrerTropPercu(tx, &paiement, &commande); err != nil {
+			return nil, "", err
+		}
+		log.Printf("Paiement %s reçu alors que la commande %s était déjà payée (%.2f/%.2f): trop-perçu à rembourser",
+			paiement.ID, commande.ID, commande.AmountPaid, commande.TotalAmount)
+		return nil, "", nil
+	}
+	if err != nil || !changed {
+		return nil, "", err
+	}
+	return &commande, "", nil
+}
+
+// WebhookHandler reçoit les webhooks de la passerelle de paiement. La signature HMAC est vérifiée
+// par la passerelle, et chaque événement n'est traité qu'une fois (dédoublonnage par ID d'événement),
+// pour qu'une livraison rejouée ne marque jamais une commande payée deux fois.
+// Méthode: POST /payments/webhook
+func (ph *PaymentHandler) WebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrWebhookInvalid)
+		return
+	}
+
+	event, err := ph.Gateway.ParseWebhook(body, r.Header)
+	if err != nil {
+		log.Printf("Webhook de paiement rejeté (passerelle: %s): %v", ph.Gateway.Name(), err)
+		if errors.Is(err, payments.ErrSignatureInvalide) {
+			respondWithError(w, r, http.StatusUnauthorized, ErrWebhookSignatureInvalid)
+			return
+		}
+		respondWithError(w, r, http.StatusBadRequest, ErrWebhookInvalid)
+		return
+	}
+
+	duplicate, ignored := false, ""
+	var commande *models.Commande
+	err = ph.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.EvenementWebhook{}).
+			Where("provider = ? AND event_id = ?", ph.Gateway.Name(), event.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			duplicate = true
+			return nil
+		}
+
+		// L'événement est enregistré dans la même transaction que son effet:
+		// en cas d'échec, rien n'est gardé et le prestataire pourra réessayer.
+		var err error
+		commande, ignored, err = ph.applyWebhookEvent(tx, event)
+		if err != nil {
+			return err
+		}
+		evenement := models.EvenementWebhook{
+			Provider:    ph.Gateway.Name(),
+			EventID:     event.ID,
+			Type:        event.Type,
+			ProviderRef: event.ProviderRef,
+			Status:      models.WebhookTraite,
+		}
+		if ignored != "" {
+			evenement.Status, evenement.Note = models.WebhookIgnore, ignored
+		}
+		return tx.Create(&evenement).Error
+	})
+	if err != nil {
+		log.Printf("Erreur DB lors du traitement du webhook (événement: %s): %v", event.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	if commande != nil {
+		ph.Events.Publish(EventOrderStatusChanged, commande.ClientID, *commande)
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"received": true, "duplicate": duplicate, "ignored": ignored != ""})
+	log.Printf("Webhook de paiement traité (événement: %s, type: %s, doublon: %v, ignoré: '%s')", event.ID, event.Type, duplicate, ignored)
+}
+
+// AdminListPaymentsHandler liste les paiements, avec un filtre optionnel par statut ou méthode.
+// Méthode: GET /admin/payments?status={statut}&method={methode}
+func (ph *PaymentHandler) AdminListPaymentsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	query := ph.DB.Order("created_at DESC")
+	if status := r.URL.Query().Get("status"); status != "" && status != "Tous" {
+		query = query.Where("status = ?", status)
+	}
+	if method := r.URL.Query().Get("method"); method != "" {
+		query = query.Where("method = ?", method)
+	}
+
+	var paiements []models.Paiement
+	if err := query.Find(&paiements).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération des paiements: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, paiements)
+}
+
+// AdminCollectPaymentHandler marque comme encaissé un paiement en espèces ou au comptoir.
+// Méthode: PUT /admin/payments/{id}/collect
+func (ph *PaymentHandler) AdminCollectPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 || parts[3] == "" { // Attendu: /admin/payments/{id}/collect
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	paiementID := parts[3]
+
+	var paiement models.Paiement
+	var commande models.Commande
+	statusChanged := false
+	err := ph.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&paiement, "id = ?", paiementID).Error; err != nil {
+			return err
+		}
+		if paiement.Method == models.PaiementCarte || paiement.Status != models.PaiementEnAttente {
+			return errDejaPaye
+		}
+		before := paiement
+		var err error
+		if commande, err = loadCommande(tx, paiement.CommandeID); err != nil {
+			return err
+		}
+		if statusChanged, err = markPaiementPaye(tx, &paiement, &commande, ActorFromContext(r.Context())); err != nil {
+			return err
+		}
+		return RecordAudit(tx, r, AuditCollect, AuditPaiement, paiement.ID, before, paiement)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondWithError(w, r, http.StatusNotFound, ErrPaymentNotFound)
+		case errors.Is(err, errDejaPaye):
+			respondWithError(w, r, http.StatusConflict, ErrPaymentNotCollectable)
+		case errors.Is(err, errTropPercu):
+			respondWithError(w, r, http.StatusConflict, ErrPaymentExceedsBalance)
+		default:
+			log.Printf("Erreur DB lors de l'encaissement du paiement (ID: %s): %v", paiementID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		}
+		return
+	}
+
+	if statusChanged {
+		ph.Events.Publish(EventOrderStatusChanged, commande.ClientID, commande)
+	}
+	respondWithJSON(w, http.StatusOK, paiement)
+	log.Printf("Paiement encaissé par admin (ID: %s, montant: %.2f)", paiement.ID, paiement.Amount)
+}
+
+// appliquerRemboursement calcule un remboursement sur le paiement et la commande, sans rien enregistrer:
+// montant remboursé et statut du paiement, montant payé (net) et remboursé de la commande.
+// Un montant nul rembourse tout ce qui reste remboursable sur le paiement. Renvoie le montant remboursé.
+func appliquerRemboursement(paiement *models.Paiement, commande *models.Commande, demande float64) (float64, error) {
+	if paiement.Status != models.PaiementPaye && paiement.Status != models.PaiementPartiellementRembourse {
+		return 0, errNonRemboursable
+	}
+	remboursable := roundMontant(paiement.Amount - paiement.RefundedAmount)
+	amount := roundMontant(demande)
+	if amount == 0 {
+		amount = remboursable
+	}
+	if amount <= 0 || amount > remboursable {
+		return 0, errMontantRemboursement
+	}
+
+	paiement.RefundedAmount = roundMontant(paiement.RefundedAmount + amount)
+	paiement.Status = models.PaiementPartiellementRembourse
+	if paiement.RefundedAmount >= roundMontant(paiement.Amount) {
+		paiement.Status = models.PaiementRembourse
+	}
+	commande.AmountPaid = roundMontant(commande.AmountPaid - amount)
+	commande.AmountRefunded = roundMontant(commande.AmountRefunded + amount)
+	return amount, nil
+}
+
+// AdminRefundPaymentHandler rembourse tout ou partie d'un paiement encaissé.
+// Sans "amount", le reste remboursable du paiement est remboursé. Le montant payé de la commande
+// est mis à jour et une commande entièrement remboursée qui n'a pas été livrée est annulée.
+// Méthode: POST /admin/payments/{id}/refund
+func (ph *PaymentHandler) AdminRefundPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 5 || parts[3] == "" { // Attendu: /admin/payments/{id}/refund
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	paiementID := parts[3]
+
+	var req struct {
+		Amount float64 `json:"amount"`
+		Reason string  `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+			return
+		}
+	}
+	if req.Amount < 0 {
+		respondWithError(w, r, http.StatusBadRequest, ErrRefundAmountInvalid)
+		return
+	}
+
+	actor := ActorFromContext(r.Context())
+	var paiement models.Paiement
+	var commande models.Commande
+	statusChanged := false
+	err := ph.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&paiement, "id = ?", paiementID).Error; err != nil {
+			return err
+		}
+		before := paiement
+		var err error
+		if commande, err = loadCommande(tx, paiement.CommandeID); err != nil {
+			return err
+		}
+		amount, err := appliquerRemboursement(&paiement, &commande, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		remboursement := models.Remboursement{
+			PaiementID: paiement.ID,
+			CommandeID: paiement.CommandeID,
+			Amount:     amount,
+			Reason:     req.Reason,
+			ActorType:  actor.Type,
+			ActorID:    actor.ID,
+		}
+		if paiement.Provider != "" && paiement.Provider != ph.Gateway.Name() {
+			return fmt.Errorf("prestataire '%s' non configuré pour le remboursement", paiement.Provider)
+		}
+		if err := tx.Create(&remboursement).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Save(&paiement).Error; err != nil {
+			return err
+		}
+		if err := RecordAudit(tx, r, AuditRefund, AuditPaiement, paiement.ID, before, paiement); err != nil {
+			return err
+		}
+		if err := tx.Model(&commande).Updates(map[string]interface{}{
+			"amount_paid":     commande.AmountPaid,
+			"amount_refunded": commande.AmountRefunded,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Commande entièrement remboursée: annulée si elle peut encore l'être
+		if commande.AmountPaid <= 0 && models.TransitionCommandeAutorisee(commande.Status, models.CommandeAnnulee) {
+			note := "Commande entièrement remboursée"
+			if req.Reason != "" {
+				note += ": " + req.Reason
+			}
+			if err := changeCommandeStatus(tx, &commande, models.CommandeAnnulee, actor, note); err != nil {
+				return err
+			}
+			statusChanged = true
+		}
+
+		// Paiement par carte: le prestataire rembourse en dernier, pour qu'un échec annule la transaction
+		if paiement.Provider != "" {
+			ref, err := ph.Gateway.Refund(paiement.ProviderRef, amount)
+			if err != nil {
+				return fmt.Errorf("passerelle %s: %w", paiement.Provider, err)
+			}
+			if err := tx.Model(&remboursement).Update("provider_ref", ref).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Preload("Remboursements").First(&paiement, "id = ?", paiement.ID).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondWithError(w, r, http.StatusNotFound, ErrPaymentNotFound)
+		case errors.Is(err, errNonRemboursable):
+			respondWithError(w, r, http.StatusConflict, ErrPaymentNotRefundable)
+		case errors.Is(err, errMontantRemboursement):
+			respondWithError(w, r, http.StatusBadRequest, ErrRefundAmountExceeded)
+		default:
+			log.Printf("Erreur lors du remboursement du paiement (ID: %s): %v", paiementID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		}
+		return
+	}
+
+	if statusChanged {
+		ph.Events.Publish(EventOrderStatusChanged, commande.ClientID, commande)
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"paiement": paiement,
+		"commande": commande,
+	})
+	log.Printf("Remboursement effectué (paiement: %s, remboursé: %.2f/%.2f)", paiement.ID, paiement.RefundedAmount, paiement.Amount)
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"restaurant-app/backend/models"
+	"restaurant-app/backend/payments"
+
+	"gorm.io/gorm"
+)
+
+func TestAppliquerRemboursement(t *testing.T) {
+	tests := []struct {
+		name          string
+		paiement      models.Paiement
+		commande      models.Commande
+		demande       float64
+		wantAmount    float64
+		wantErr       error
+		wantStatus    string
+		wantRefunded  float64 // Remboursé sur le paiement
+		wantPaid      float64 // Payé (net) sur la commande
+		wantCmdRefund float64 // Remboursé sur la commande
+	}{
+		{
+			name:     "remboursement total sans montant",
+			paiement: models.Paiement{Amount: 30, Status: models.PaiementPaye},
+			commande: models.Commande{TotalAmount: 30, AmountPaid: 30},
+			demande:  0, wantAmount: 30,
+			wantStatus: models.PaiementRembourse, wantRefunded: 30, wantPaid: 0, wantCmdRefund: 30,
+		},
+		{
+			name:     "remboursement partiel",
+			paiement: models.Paiement{Amount: 30, Status: models.PaiementPaye},
+			commande: models.Commande{TotalAmount: 30, AmountPaid: 30},
+			demande:  12.5, wantAmount: 12.5,
+			wantStatus: models.PaiementPartiellementRembourse, wantRefunded: 12.5, wantPaid: 17.5, wantCmdRefund: 12.5,
+		},
+		{
+			name:     "reste remboursable après un premier remboursement",
+			paiement: models.Paiement{Amount: 30, RefundedAmount: 10, Status: models.PaiementPartiellementRembourse},
+			commande: models.Commande{TotalAmount: 30, AmountPaid: 20, AmountRefunded: 10},
+			demande:  0, wantAmount: 20,
+			wantStatus: models.PaiementRembourse, wantRefunded: 30, wantPaid: 0, wantCmdRefund: 30,
+		},
+		{
+			name:     "arrondi au centime",
+			paiement: models.Paiement{Amount: 0.3, RefundedAmount: 0.1, Status: models.PaiementPartiellementRembourse},
+			commande: models.Commande{TotalAmount: 0.3, AmountPaid: 0.2, AmountRefunded: 0.1},
+			demande:  0.2, wantAmount: 0.2,
+			wantStatus: models.PaiementRembourse, wantRefunded: 0.3, wantPaid: 0, wantCmdRefund: 0.3,
+		},
+		{
+			name:     "commande payée en plusieurs fois",
+			paiement: models.Paiement{Amount: 20, Status: models.PaiementPaye},
+			commande: models.Commande{TotalAmount: 50, AmountPaid: 50},
+			demande:  5, wantAmount: 5,
+			wantStatus: models.PaiementPartiellementRembourse, wantRefunded: 5, wantPaid: 45, wantCmdRefund: 5,
+		},
+		{
+			name:     "montant supérieur au reste remboursable",
+			paiement: models.Paiement{Amount: 30, RefundedAmount: 25, Status: models.PaiementPartiellementRembourse},
+			commande: models.Commande{TotalAmount: 30, AmountPaid: 5, AmountRefunded: 25},
+			demande:  5.01, wantErr: errMontantRemboursement,
+		},
+		{
+			name:     "montant négatif",
+			paiement: models.Paiement{Amount: 30, Status: models.PaiementPaye},
+			commande: models.Commande{TotalAmount: 30, AmountPaid: 30},
+			demande:  -1, wantErr: errMontantRemboursement,
+		},
+		{
+			name:     "paiement en attente",
+			paiement: models.Paiement{Amount: 30, Status: models.PaiementEnAttente},
+			commande: models.Commande{TotalAmount: 30},
+			wantErr:  errNonRemboursable,
+		},
+		{
+			name:     "paiement déjà remboursé",
+			paiement: models.Paiement{Amount: 30, RefundedAmount: 30, Status: models.PaiementRembourse},
+			commande: models.Commande{TotalAmount: 30, AmountRefunded: 30},
+			wantErr:  errNonRemboursable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paiement, commande := tt.paiement, tt.commande
+			amount, err := appliquerRemboursement(&paiement, &commande, tt.demande)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("erreur = %v, attendu %v", err, tt.wantErr)
+				}
+				if paiement.Status != tt.paiement.Status || paiement.RefundedAmount != tt.paiement.RefundedAmount ||
+					commande.AmountPaid != tt.commande.AmountPaid {
+					t.Errorf("paiement ou commande modifié malgré l'erreur")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erreur inattendue: %v", err)
+			}
+			if amount != tt.wantAmount {
+				t.Errorf("montant remboursé = %v, attendu %v", amount, tt.wantAmount)
+			}
+			if paiement.Status != tt.wantStatus || paiement.RefundedAmount != tt.wantRefunded {
+				t.Errorf("paiement = (%q, %v), attendu (%q, %v)", paiement.Status, paiement.RefundedAmount, tt.wantStatus, tt.wantRefunded)
+			}
+			if commande.AmountPaid != tt.wantPaid || commande.AmountRefunded != tt.wantCmdRefund {
+				t.Errorf("commande payé/remboursé = %v/%v, attendu %v/%v",
+					commande.AmountPaid, commande.AmountRefunded, tt.wantPaid, tt.wantCmdRefund)
+			}
+			// Le remboursement ne rouvre pas le solde de la commande
+			if reste, want := resteAPayer(commande), resteAPayer(tt.commande); reste != want {
+				t.Errorf("resteAPayer = %v, attendu %v", reste, want)
+			}
+		})
+	}
+}
+
+func TestMarkPaiementPaye(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string  // Statut de la commande
+		total, paid float64 // Total et montant déjà payé (net des remboursements)
+		refunded    float64 // Montant déjà remboursé
+		amount      float64 // Paiement encaissé
+		wantErr     error
+		wantChanged bool
+		wantStatus  string
+		wantPaid    float64
+		wantOther   string // Statut de l'autre paiement en attente de la commande
+	}{
+		{"commande en attente entièrement payée", models.CommandeEnAttente, 30, 0, 0, 30,
+			nil, true, models.CommandeConfirmee, 30, models.PaiementRemplace},
+		{"commande déjà confirmée", models.CommandeConfirmee, 30, 0, 0, 30,
+			nil, false, models.CommandeConfirmee, 30, models.PaiementRemplace},
+		{"paiement partiel", models.CommandeEnAttente, 30, 0, 0, 10,
+			nil, true, models.CommandeConfirmee, 10, models.PaiementEnAttente},
+		{"paiement après un remboursement partiel", models.CommandeLivree, 30, 20, 10, 10,
+			errTropPercu, false, models.CommandeLivree, 20, models.PaiementEnAttente},
+		{"paiement au-delà du reste à payer", models.CommandeConfirmee, 30, 30, 0, 30,
+			errTropPercu, false, models.CommandeConfirmee, 30, models.PaiementEnAttente},
+		{"paiement dépassant d'un centime", models.CommandeEnAttente, 30, 20, 0, 10.01,
+			errTropPercu, false, models.CommandeEnAttente, 20, models.PaiementEnAttente},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			client := seedClient(t, db, "client@test.fr")
+			commande := seedCommande(t, db, client.ID, tt.status, tt.total, tt.paid)
+			commande.AmountRefunded = tt.refunded
+			db.Model(&commande).Update("amount_refunded", tt.refunded)
+			paiement := models.Paiement{CommandeID: commande.ID, Amount: tt.amount, Method: models.PaiementComptoir, Status: models.PaiementEnAttente}
+			other := models.Paiement{CommandeID: commande.ID, Amount: tt.amount, Method: models.PaiementCarte, Status: models.PaiementEnAttente}
+			db.Create(&paiement)
+			db.Create(&other)
+
+			var changed bool
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				changed, err = markPaiementPaye(tx, &paiement, &commande, Actor{Type: ActorAdmin, ID: "admin"})
+				return err
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("markPaiementPaye() = %v, attendu %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changement de statut = %v, attendu %v", changed, tt.wantChanged)
+			}
+
+			var stored models.Commande
+			db.First(&stored, "id = ?", commande.ID)
+			if stored.Status != tt.wantStatus || stored.AmountPaid != tt.wantPaid {
+				t.Errorf("commande = (%q, %v), attendu (%q, %v)", stored.Status, stored.AmountPaid, tt.wantStatus, tt.wantPaid)
+			}
+			var storedPaiement, storedOther models.Paiement
+			db.First(&storedPaiement, "id = ?", paiement.ID)
+			db.First(&storedOther, "id = ?", other.ID)
+			wantPaiement := models.PaiementPaye
+			if tt.wantErr != nil {
+				wantPaiement = models.PaiementEnAttente
+			}
+			if storedPaiement.Status != wantPaiement {
+				t.Errorf("statut du paiement = %q, attendu %q", storedPaiement.Status, wantPaiement)
+			}
+			if storedOther.Status != tt.wantOther {
+				t.Errorf("statut de l'autre paiement = %q, attendu %q", storedOther.Status, tt.wantOther)
+			}
+
+			var receipts, history int64
+			db.Model(&models.MessageSortant{}).Where("kind = ? AND reference_id = ?", MessageRecuCommande, commande.ID).Count(&receipts)
+			db.Model(&models.CommandeStatutHistorique{}).Where("commande_id = ?", commande.ID).Count(&history)
+			wantReceipts, wantHistory := int64(1), int64(0)
+			if tt.wantErr != nil {
+				wantReceipts = 0
+			}
+			if tt.wantChanged {
+				wantHistory = 1
+			}
+			if receipts != wantReceipts || history != wantHistory {
+				t.Errorf("reçus/historique = %d/%d, attendu %d/%d", receipts, history, wantReceipts, wantHistory)
+			}
+		})
+	}
+}
+
+// webhookRequest construit un webhook de la passerelle factice signé avec le secret donné.
+func webhookRequest(secret string, ts time.Time, eventID, eventType, providerRef string) *http.Request {
+	body := `{"event_id":"` + eventID + `","type":"` + eventType + `","provider_ref":"` + providerRef + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/payments/webhook", strings.NewReader(body))
+	req.Header.Set(payments.TimestampHeader, strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set(payments.SignatureHeader, payments.Sign(secret, ts.Unix(), []byte(body)))
+	return req
+}
+
+func TestPayOrderAfterPartialRefund(t *testing.T) {
+	db := newTestDB(t)
+	ph := NewPaymentHandler(db, NewEventBroker(), &payments.FakeGateway{})
+	client := seedClient(t, db, "client@test.fr")
+	// Plat manquant: 10 remboursés sur une commande de 30 payée puis livrée
+	commande := seedCommande(t, db, client.ID, models.CommandeLivree, 30, 20)
+	db.Model(&commande).Update("amount_refunded", 10)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/orders/"+commande.ID+"/pay", strings.NewReader(`{"method":"comptoir"}`))
+	req = req.WithContext(WithClientID(req.Context(), client.ID))
+	rec := httptest.NewRecorder()
+	ph.PayOrderHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("paiement d'une commande remboursée: statut %d, attendu %d", rec.Code, http.StatusConflict)
+	}
+	var count int64
+	db.Model(&models.Paiement{}).Where("commande_id = ?", commande.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("%d paiements créés pour la somme remboursée, attendu 0", count)
+	}
+}
+
+func TestWebhookHandlerDeduplication(t *testing.T) {
+	const secret = "secret-webhook"
+	now := time.Now()
+	db := newTestDB(t)
+	ph := NewPaymentHandler(db, NewEventBroker(), &payments.FakeGateway{WebhookSecret: secret, Now: func() time.Time { return now }})
+	client := seedClient(t, db, "client@test.fr")
+	commande := seedCommande(t, db, client.ID, models.CommandeEnAttente, 42, 0)
+	paiement := models.Paiement{CommandeID: commande.ID, Amount: 42, Method: models.PaiementCarte,
+		Status: models.PaiementEnAttente, Provider: "fake", ProviderRef: "fake_ref"}
+	db.Create(&paiement)
+
+	steps := []struct {
+		name          string
+		req           *http.Request
+		wantCode      int
+		wantDuplicate bool
+		wantEvents    int64  // Événements enregistrés après l'appel
+		wantEvent     string // Statut de l'événement enregistré (vide si aucun)
+	}{
+		{"paiement réussi", webhookRequest(secret, now, "evt_1", payments.EventPaymentSucceeded, "fake_ref"),
+			http.StatusOK, false, 1, models.WebhookTraite},
+		{"même événement rejoué", webhookRequest(secret, now, "evt_1", payments.EventPaymentSucceeded, "fake_ref"),
+			http.StatusOK, true, 1, models.WebhookTraite},
+		{"autre événement pour le même paiement", webhookRequest(secret, now, "evt_2", payments.EventPaymentSucceeded, "fake_ref"),
+			http.StatusOK, false, 2, models.WebhookIgnore},
+		{"échec après encaissement", webhookRequest(secret, now, "evt_3", payments.EventPaymentFailed, "fake_ref"),
+			http.StatusOK, false, 3, models.WebhookIgnore},
+		{"paiement inconnu", webhookRequest(secret, now, "evt_4", payments.EventPaymentSucceeded, "fake_inconnu"),
+			http.StatusOK, false, 4, models.WebhookIgnore},
+		{"mauvaise signature", webhookRequest("autre-secret", now, "evt_5", payments.EventPaymentSucceeded, "fake_ref"),
+			http.StatusUnauthorized, false, 4, ""},
+		{"signature périmée", webhookRequest(secret, now.Add(-time.Hour), "evt_6", payments.EventPaymentSucceeded, "fake_ref"),
+			http.StatusUnauthorized, false, 4, ""},
+	}
+	for _, step := range steps {
+		rec := httptest.NewRecorder()
+		ph.WebhookHandler(rec, step.req)
+		if rec.Code != step.wantCode {
+			t.Fatalf("%s: code = %d, attendu %d (%s)", step.name, rec.Code, step.wantCode, rec.Body.String())
+		}
+		if step.wantCode == http.StatusOK && strings.Contains(rec.Body.String(), `"duplicate":true`) != step.wantDuplicate {
+			t.Errorf("%s: réponse %s, doublon attendu: %v", step.name, rec.Body.String(), step.wantDuplicate)
+		}
+		var count int64
+		db.Model(&models.EvenementWebhook{}).Count(&count)
+		if count != step.wantEvents {
+			t.Errorf("%s: %d événements enregistrés, attendu %d", step.name, count, step.wantEvents)
+		}
+		if step.wantEvent != "" {
+			var last models.EvenementWebhook
+			db.Order("created_at DESC").First(&last)
+			if last.Status != step.wantEvent {
+				t.Errorf("%s: événement %s au statut %q, attendu %q", step.name, last.EventID, last.Status, step.wantEvent)
+			}
+		}
+
+		// Quelle que soit la livraison, la commande n'est payée qu'une fois
+		var stored models.Commande
+		db.First(&stored, "id = ?", commande.ID)
+		if stored.AmountPaid != 42 || stored.Status != models.CommandeConfirmee {
+			t.Errorf("%s: commande = (%q, %v), attendu (%q, 42)", step.name, stored.Status, stored.AmountPaid, models.CommandeConfirmee)
+		}
+	}
+
+	var receipts int64
+	db.Model(&models.MessageSortant{}).Where("kind = ?", MessageRecuCommande).Count(&receipts)
+	if receipts != 1 {
+		t.Errorf("%d reçus envoyés, attendu 1", receipts)
+	}
+}
+
+func TestWebhookReplacedPaymentAfterOrderPaid(t *testing.T) {
+	const secret = "secret-webhook"
+	db := newTestDB(t)
+	ph := NewPaymentHandler(db, NewEventBroker(), &payments.FakeGateway{WebhookSecret: secret})
+	client := seedClient(t, db, "client@test.fr")
+	// Payée au comptoir pendant que l'ancienne page de paiement par carte restait ouverte
+	commande := seedCommande(t, db, client.ID, models.CommandeConfirmee, 42, 42)
+	paiement := models.Paiement{CommandeID: commande.ID, Amount: 42, Method: models.PaiementCarte,
+		Status: models.PaiementRemplace, Provider: "fake", ProviderRef: "fake_ancien"}
+	db.Create(&paiement)
+
+	rec := httptest.NewRecorder()
+	ph.WebhookHandler(rec, webhookRequest(secret, time.Now(), "evt_1", payments.EventPaymentSucceeded, "fake_ancien"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("webhook: statut %d, attendu %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var stored models.Paiement
+	var storedCommande models.Commande
+	db.First(&stored, "id = ?", paiement.ID)
+	db.First(&storedCommande, "id = ?", commande.ID)
+	if stored.Status != models.PaiementPaye || storedCommande.AmountPaid != 84 || storedCommande.Status != models.CommandeConfirmee {
+		t.Fatalf("après le webhook: paiement %q, commande (%q, %v), attendu paiement payé et commande (%q, 84)",
+			stored.Status, storedCommande.Status, storedCommande.AmountPaid, models.CommandeConfirmee)
+	}
+
+	// Le trop-perçu est remboursable comme tout paiement encaissé
+	owner := seedClient(t, db, "owner@test.fr")
+	owner.Role = models.RoleOwner
+	req := httptest.NewRequest(http.MethodPost, "/admin/payments/"+paiement.ID+"/refund", nil)
+	req = req.WithContext(WithStaff(req.Context(), owner))
+	rec = httptest.NewRecorder()
+	ph.AdminRefundPaymentHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("remboursement du trop-perçu: statut %d, attendu %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	db.First(&storedCommande, "id = ?", commande.ID)
+	if storedCommande.AmountPaid != 42 || storedCommande.Status != models.CommandeConfirmee || resteAPayer(storedCommande) > 0 {
+		t.Errorf("après remboursement: commande (%q, %v), attendu (%q, 42) et soldée",
+			storedCommande.Status, storedCommande.AmountPaid, models.CommandeConfirmee)
+	}
+}
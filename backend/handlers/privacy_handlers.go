@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCompteDuPersonnel est renvoyée quand on tente d'effacer un compte qui a encore un rôle du personnel.
+	ErrCompteDuPersonnel = errors.New("compte du personnel: retirer le rôle avant l'effacement")
+	// ErrDejaAnonymise est renvoyée quand les données du client ont déjà été effacées.
+	ErrDejaAnonymise = errors.New("client déjà anonymisé")
+)
+
+// anonymousName remplace le nom du client et des réservations effacés.
+const anonymousName = "Anonyme"
+
+// ClientExport regroupe tout ce que le restaurant conserve sur un client (droit d'accès, RGPD).
+type ClientExport struct {
+	ExportedAt    time.Time               `json:"exported_at"`
+	Profile       map[string]interface{}  `json:"profile"`
+	Reservations  []models.Reservation    `json:"reservations"` // Liées au compte ou faites avec son email confirmé
+	Orders        []models.Commande       `json:"orders"`
+	Payments      []models.Paiement       `json:"payments"`
+	Notifications []models.Notification   `json:"notifications"`
+	Messages      []models.MessageSortant `json:"messages"` // Emails et SMS envoyés au client
+	Sessions      []models.SessionClient  `json:"sessions"` // Connexions (appareil, adresse IP)
+}
+
+// BuildClientExport rassemble les données d'un client.
+func BuildClientExport(db *gorm.DB, clientID string) (ClientExport, error) {
+	export := ClientExport{ExportedAt: time.Now()}
+	var client models.Client
+	if err := db.First(&client, "id = ?", clientID).Error; err != nil {
+		return export, err
+	}
+	export.Profile = Profile(client)
+
+	if err := clientReservations(db, client).Order("reservation_date DESC").Find(&export.Reservations).Error; err != nil {
+		return export, err
+	}
+	if err := db.Preload("Lignes").Preload("Historique").Where("client_id = ?", client.ID).
+		Order("order_date DESC").Find(&export.Orders).Error; err != nil {
+		return export, err
+	}
+	if err := db.Preload("Remboursements").
+		Where("commande_id IN (?)", db.Model(&models.Commande{}).Select("id").Where("client_id = ?", client.ID)).
+		Order("payment_date DESC").Find(&export.Payments).Error; err != nil {
+		return export, err
+	}
+	if err := db.Where("client_id = ?", client.ID).Order("created_at DESC").Find(&export.Notifications).Error; err != nil {
+		return export, err
+	}
+	if err := db.Where("recipient IN ?", clientRecipients(client)).Order("created_at DESC").Find(&export.Messages).Error; err != nil {
+		return export, err
+	}
+	if err := db.Where("client_id = ?", client.ID).Order("created_at DESC").Find(&export.Sessions).Error; err != nil {
+		return export, err
+	}
+	return export, nil
+}
+
+// clientReservations restreint la requête aux réservations du client: celles liées à son compte et,
+// si son email est confirmé, celles faites avec cet email. Un email non confirmé peut être celui
+// d'un tiers: ses réservations ne sont alors ni exportées ni effacées.
+func clientReservations(db *gorm.DB, client models.Client) *gorm.DB {
+	if client.EmailVerifie {
+		return db.Where("client_id = ? OR LOWER(client_email) = LOWER(?)", client.ID, client.Email)
+	}
+	return db.Where("client_id = ?", client.ID)
+}
+
+// clientRecipients renvoie les destinataires (email, téléphone) des messages envoyés au client.
+func clientRecipients(client models.Client) []string {
+	recipients := []string{client.Email}
+	if client.NumTel != "" {
+		recipients = append(recipients, client.NumTel)
+	}
+	return recipients
+}
+
+// clientAuditEntries renvoie les entrées du journal d'audit qui contiennent des données du client:
+// celles de son compte, celles de ses réservations (reservationIDs) et celles des réservations supprimées
+// depuis, reconnues au compte ou, si son email est confirmé, à l'email de leur état enregistré,
+// ainsi que les levées de verrouillage enregistrées avec l'email du compte.
+func clientAuditEntries(db *gorm.DB, client models.Client, reservationIDs []string) ([]models.JournalAudit, error) {
+	var entries []models.JournalAudit
+	if err := db.Where("entity_type = ? AND entity_id = ?", AuditClient, client.ID).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	query := db.Where("entity_type = ?", AuditReservation)
+	match := db.Where("changes LIKE ?", "%"+client.ID+"%")
+	if client.EmailVerifie {
+		match = match.Or("LOWER(changes) LIKE LOWER(?)", "%"+client.Email+"%")
+	}
+	if len(reservationIDs) > 0 {
+		match = match.Or("entity_id IN ?", reservationIDs)
+	}
+	var candidates []models.JournalAudit
+	if err := query.Where(match).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	var lockouts []models.JournalAudit
+	if err := db.Where("entity_type = ? AND LOWER(changes) LIKE LOWER(?)", AuditLockout, "%"+client.Email+"%").
+		Find(&lockouts).Error; err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, lockouts...)
+
+	owned := map[string]bool{}
+	for _, id := range reservationIDs {
+		owned[id] = true
+	}
+	for _, entry := range candidates {
+		if owned[entry.EntityID] || auditConcernsClient(entry, client) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// auditConcernsClient vérifie qu'une entrée d'audit d'une réservation ou d'un compteur d'échecs désigne
+// bien le client, la recherche par LIKE pouvant trouver l'identifiant ou l'email dans un autre champ.
+func auditConcernsClient(entry models.JournalAudit, client models.Client) bool {
+	var changes map[string]AuditChange
+	if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+		return false
+	}
+	isID := func(v string) bool { return v == client.ID }
+	isEmail := func(v string) bool { return strings.EqualFold(v, client.Email) }
+	if entry.EntityType == AuditLockout {
+		return auditFieldMatches(changes, "value", isEmail)
+	}
+	return auditFieldMatches(changes, "client_id", isID) ||
+		client.EmailVerifie && auditFieldMatches(changes, "client_email", isEmail)
+}
+
+// auditFieldMatches indique si l'ancienne ou la nouvelle valeur du champ est une chaîne acceptée par match.
+func auditFieldMatches(changes map[string]AuditChange, field string, match func(string) bool) bool {
+	for _, v := range []interface{}{changes[field].Before, changes[field].After} {
+		if s, ok := v.(string); ok && match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeExport envoie l'export en JSON (par défaut) ou en archive ZIP (?format=zip, un fichier par rubrique).
+func writeExport(w http.ResponseWriter, r *http.Request, export ClientExport) {
+	filename := "donnees-client-" + export.ExportedAt.Format("2006-01-02")
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		respondWithJSON(w, http.StatusOK, export)
+	case "zip":
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+		w.WriteHeader(http.StatusOK)
+		archive := zip.NewWriter(w)
+		sections := []struct {
+			name string
+			data interface{}
+		}{
+			{"profil.json", export.Profile},
+			{"reservations.json", export.Reservations},
+			{"commandes.json", export.Orders},
+			{"paiements.json", export.Payments},
+			{"notifications.json", export.Notifications},
+			{"messages.json", export.Messages},
+			{"sessions.json", export.Sessions},
+		}
+		for _, section := range sections {
+			f, err := archive.CreateHeader(&zip.FileHeader{Name: section.name, Method: zip.Deflate, Modified: export.ExportedAt})
+			if err == nil {
+				encoder := json.NewEncoder(f)
+				encoder.SetIndent("", "  ")
+				err = encoder.Encode(section.data)
+			}
+			if err != nil {
+				log.Printf("Erreur lors de l'écriture de l'archive d'export (%s): %v", section.name, err)
+				return
+			}
+		}
+		if err := archive.Close(); err != nil {
+			log.Printf("Erreur lors de la fermeture de l'archive d'export: %v", err)
+		}
+	default:
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("format", FieldInvalid))
+	}
+}
+
+// ErasureSummary résume l'effacement des données d'un client.
+type ErasureSummary struct {
+	ClientID               string `json:"client_id"`
+	ReservationsAnonymized int64  `json:"reservations_anonymized"`
+	OrdersKept             int64  `json:"orders_kept"` // Commandes et paiements conservés pour la comptabilité
+	NotificationsDeleted   int64  `json:"notifications_deleted"`
+	MessagesDeleted        int64  `json:"messages_deleted"`
+}
+
+// EraseClient efface les données personnelles d'un client (droit à l'effacement): le compte est anonymisé
+// et ne permet plus de se connecter, ses réservations (voir clientReservations) perdent nom, email, téléphone et notes,
+// les notifications, messages, sessions, jetons et le panier sont supprimés.
+// Les commandes, paiements et remboursements sont conservés pour la comptabilité,
+// rattachés au compte anonymisé. Les entrées du journal d'audit qui le concernent perdent ses données personnelles
+// (voir RedactAudit). L'effacement est tracé dans le journal d'audit, dans la même transaction,
+// au nom de l'auteur de la requête r (le client lui-même ou un membre du personnel).
+func EraseClient(db *gorm.DB, r *http.Request, clientID string) (ErasureSummary, error) {
+	summary := ErasureSummary{ClientID: clientID}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var client models.Client
+		if err := tx.First(&client, "id = ?", clientID).Error; err != nil {
+			return err
+		}
+		if client.AnonymiseLe != nil {
+			return ErrDejaAnonymise
+		}
+		if client.Role != "" {
+			return ErrCompteDuPersonnel
+		}
+
+		var reservationIDs []string
+		if err := clientReservations(tx.Model(&models.Reservation{}), client).Pluck("id", &reservationIDs).Error; err != nil {
+			return err
+		}
+		// Le journal garde la trace des modifications, mais plus les données personnelles du client
+		entries, err := clientAuditEntries(tx, client, reservationIDs)
+		if err != nil {
+			return err
+		}
+		if err := RedactAudit(tx, entries); err != nil {
+			return err
+		}
+
+		result := clientReservations(tx.Model(&models.Reservation{}), client).
+			Updates(map[string]interface{}{
+				"client_name": anonymousName, "client_email": "", "client_phone": "",
+				"special_notes": "", "event_description": "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.ReservationsAnonymized = result.RowsAffected
+
+		result = tx.Where("recipient IN ?", clientRecipients(client)).Delete(&models.MessageSortant{})
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.MessagesDeleted = result.RowsAffected
+
+		result = tx.Where("client_id = ?", client.ID).Delete(&models.Notification{})
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.NotificationsDeleted = result.RowsAffected
+
+		if err := tx.Where("panier_id IN (?)", tx.Model(&models.Panier{}).Select("id").Where("client_id = ?", client.ID)).
+			Delete(&models.PanierPlat{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{
+			&models.Panier{}, &models.SessionClient{}, &models.JetonReinitialisation{}, &models.JetonVerification{},
+			&models.CodeSecours{}, &models.DefiConnexion{},
+		} {
+			if err := tx.Where("client_id = ?", client.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// Compteurs d'échecs de connexion et de demandes de réinitialisation, tenus par email
+		kinds := []string{models.LimiteParEmail, models.LimiteReinitEmail}
+		if err := tx.Where("kind IN ? AND value = ?", kinds, normalizeEmail(client.Email)).
+			Delete(&models.LimiteConnexion{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Commande{}).Where("client_id = ?", client.ID).Count(&summary.OrdersKept).Error; err != nil {
+			return err
+		}
+		// L'email reste unique et ne correspond à aucune adresse réelle; sans mot de passe, la connexion est impossible
+		now := time.Now()
+		if err := tx.Model(&client).Updates(map[string]interface{}{
+			"email":               "anonyme-" + client.ID + "@anonyme.invalid",
+			"nom_client":          anonymousName,
+			"prenom_client":       "",
+			"num_tel":             "",
+			"adresse":             "",
+			"mot_de_passe_hashed": "",
+			"email_verifie":       false,
+			"totp_secret":         "",
+			"totp_active":         false,
+			"anonymise_le":        now,
+		}).Error; err != nil {
+			return err
+		}
+		// Seul l'effacement est tracé: les données effacées ne sont pas recopiées dans le journal
+		return RecordAudit(tx, r, AuditErase, AuditClient, client.ID,
+			map[string]interface{}{"anonymiseLe": nil}, map[string]interface{}{"anonymiseLe": now})
+	})
+	return summary, err
+}
+
+// RespondErasureError envoie l'erreur correspondant à un échec d'EraseClient.
+func RespondErasureError(w http.ResponseWriter, r *http.Request, clientID string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		respondWithError(w, r, http.StatusNotFound, ErrClientNotFound)
+	case errors.Is(err, ErrDejaAnonymise):
+		respondWithError(w, r, http.StatusConflict, ErrClientErased)
+	case errors.Is(err, ErrCompteDuPersonnel):
+		respondWithError(w, r, http.StatusConflict, ErrStaffAccountNotDeletable)
+	default:
+		log.Printf("Erreur DB lors de l'effacement des données du client (ID: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+	}
+}
+
+// PrivacyHandler gère l'export des données des clients (RGPD).
+type PrivacyHandler struct {
+	DB *gorm.DB
+}
+
+// NewPrivacyHandler crée une nouvelle instance de PrivacyHandler.
+func NewPrivacyHandler(db *gorm.DB) *PrivacyHandler {
+	return &PrivacyHandler{DB: db}
+}
+
+// MyExportHandler renvoie toutes les données du client connecté, en JSON ou en ZIP (?format=zip).
+// Méthode: GET /api/me/export
+func (ph *PrivacyHandler) MyExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+	export, err := BuildClientExport(ph.DB, clientID)
+	if err != nil {
+		log.Printf("Erreur DB lors de l'export des données (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	writeExport(w, r, export)
+	log.Printf("Données exportées par le client (ID: %s)", clientID)
+}
+
+// AdminExportHandler renvoie toutes les données d'un client, pour répondre à une demande reçue hors de l'application.
+// Méthode: GET /admin/clients/{id}/export
+func (ph *PrivacyHandler) AdminExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	// Attendu: ["", "admin", "clients", id, "export"]
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) != 5 || parts[3] == "" || parts[4] != "export" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	export, err := BuildClientExport(ph.DB, parts[3])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusNotFound, ErrClientNotFound)
+			return
+		}
+		log.Printf("Erreur DB lors de l'export des données (client: %s): %v", parts[3], err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	writeExport(w, r, export)
+	log.Printf("Données du client %s exportées par %s", parts[3], ActorFromContext(r.Context()).ID)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// seedReservation crée une réservation au nom et à l'email donnés, liée au compte si clientID n'est pas vide.
+func seedReservation(t *testing.T, db *gorm.DB, clientID, name, email string) models.Reservation {
+	t.Helper()
+	reservation := models.Reservation{
+		ClientID: clientID, ClientName: name, ClientEmail: email, ClientPhone: "0600000000",
+		NumGuests: 2, ReservationDate: time.Now().Add(24 * time.Hour),
+	}
+	if err := db.Create(&reservation).Error; err != nil {
+		t.Fatalf("création de la réservation: %v", err)
+	}
+	return reservation
+}
+
+func TestPrivacyMatchesEmailOnlyWhenVerified(t *testing.T) {
+	for _, verified := range []bool{true, false} {
+		db := newTestDB(t)
+		client := seedClient(t, db, "client@test.fr")
+		if err := db.Model(&client).Update("email_verifie", verified).Error; err != nil {
+			t.Fatalf("mise à jour du client: %v", err)
+		}
+		own := seedReservation(t, db, client.ID, "Client", "client@test.fr")
+		// Réservation faite sans compte avec la même adresse (casse différente)
+		guest := seedReservation(t, db, "", "Quelqu'un", "Client@Test.fr")
+
+		export, err := BuildClientExport(db, client.ID)
+		if err != nil {
+			t.Fatalf("BuildClientExport: %v", err)
+		}
+		wantExported := 1
+		if verified {
+			wantExported = 2
+		}
+		if len(export.Reservations) != wantExported {
+			t.Errorf("email confirmé %v: %d réservations exportées, attendu %d", verified, len(export.Reservations), wantExported)
+		}
+
+		summary, err := EraseClient(db, httptest.NewRequest(http.MethodDelete, "/api/me", nil), client.ID)
+		if err != nil {
+			t.Fatalf("EraseClient: %v", err)
+		}
+		if summary.ReservationsAnonymized != int64(wantExported) {
+			t.Errorf("email confirmé %v: %d réservations anonymisées, attendu %d", verified, summary.ReservationsAnonymized, wantExported)
+		}
+		var gotOwn, gotGuest models.Reservation
+		db.First(&gotOwn, "id = ?", own.ID)
+		db.First(&gotGuest, "id = ?", guest.ID)
+		if gotOwn.ClientName != anonymousName || gotOwn.ClientEmail != "" {
+			t.Errorf("réservation du compte non anonymisée: %+v", gotOwn)
+		}
+		if anonymized := gotGuest.ClientName == anonymousName; anonymized != verified {
+			t.Errorf("email confirmé %v: réservation sans compte anonymisée = %v", verified, anonymized)
+		}
+	}
+}
+
+func TestEraseClientRedactsAudit(t *testing.T) {
+	db := newTestDB(t)
+	client := seedClient(t, db, "client@test.fr")
+	req := httptest.NewRequest(http.MethodPut, "/admin/clients/"+client.ID, nil)
+	audit := func(action, entityType, entityID string, before, after interface{}) {
+		t.Helper()
+		if err := RecordAudit(db, req, action, entityType, entityID, before, after); err != nil {
+			t.Fatalf("RecordAudit: %v", err)
+		}
+	}
+
+	updated := client
+	updated.NumTel, updated.Adresse = "0611223344", "1 rue de la Paix"
+	audit(AuditUpdate, AuditClient, client.ID, ClientAuditView(client), ClientAuditView(updated))
+	own := seedReservation(t, db, client.ID, "Client", "client@test.fr")
+	confirmed := own
+	confirmed.Status, confirmed.SpecialNotes = "Confirmée", "Allergie aux noix"
+	audit(AuditUpdate, AuditReservation, own.ID, own, confirmed)
+	// Réservation sans compte faite avec l'email du client, supprimée depuis
+	guest := seedReservation(t, db, "", "Client", "CLIENT@test.fr")
+	audit(AuditDelete, AuditReservation, guest.ID, guest, nil)
+	if err := db.Delete(&guest).Error; err != nil {
+		t.Fatalf("suppression de la réservation: %v", err)
+	}
+	other := seedReservation(t, db, "", "Quelqu'un", "autre@test.fr")
+	audit(AuditDelete, AuditReservation, other.ID, other, nil)
+	// Levée de verrouillage enregistrée avec l'email du compteur
+	lockout := models.LimiteConnexion{ID: "limite-email", Kind: models.LimiteParEmail, Value: "client@test.fr", Failures: 5}
+	audit(AuditDelete, AuditLockout, lockout.ID, lockout, nil)
+	otherLockout := models.LimiteConnexion{ID: "limite-autre", Kind: models.LimiteParEmail, Value: "autre@test.fr", Failures: 5}
+	audit(AuditDelete, AuditLockout, otherLockout.ID, otherLockout, nil)
+
+	if _, err := EraseClient(db, httptest.NewRequest(http.MethodDelete, "/api/me", nil), client.ID); err != nil {
+		t.Fatalf("EraseClient: %v", err)
+	}
+
+	changes := func(entityID, action string) map[string]AuditChange {
+		t.Helper()
+		var entry models.JournalAudit
+		if err := db.Where("entity_id = ? AND action = ?", entityID, action).First(&entry).Error; err != nil {
+			t.Fatalf("entrée d'audit %s de %s: %v", action, entityID, err)
+		}
+		var c map[string]AuditChange
+		if err := json.Unmarshal(entry.Changes, &c); err != nil {
+			t.Fatalf("diff de l'entrée d'audit: %v", err)
+		}
+		return c
+	}
+	if c := changes(client.ID, AuditUpdate); c["numTel"].After != auditRedacted || c["adresse"].After != auditRedacted {
+		t.Errorf("mise à jour du compte non effacée du journal: %+v", c)
+	}
+	c := changes(own.ID, AuditUpdate)
+	if c["special_notes"].After != auditRedacted || c["status"].After != "Confirmée" {
+		t.Errorf("mise à jour de la réservation: %+v, attendu les notes effacées et le statut conservé", c)
+	}
+	if c := changes(guest.ID, AuditDelete); c["client_email"].Before != auditRedacted || c["client_phone"].Before != auditRedacted {
+		t.Errorf("réservation supprimée avec l'email du client non effacée du journal: %+v", c)
+	}
+	if c := changes(other.ID, AuditDelete); c["client_email"].Before != "autre@test.fr" {
+		t.Errorf("réservation d'un tiers effacée du journal: %+v", c)
+	}
+	if c := changes(lockout.ID, AuditDelete); c["value"].Before != auditRedacted || c["failures"].Before != float64(5) {
+		t.Errorf("levée de verrouillage: %+v, attendu l'email effacé et le nombre d'échecs conservé", c)
+	}
+	if c := changes(otherLockout.ID, AuditDelete); c["value"].Before != "autre@test.fr" {
+		t.Errorf("levée de verrouillage d'un autre email effacée du journal: %+v", c)
+	}
+}
+
+func TestEraseClientDeletesThrottleCounters(t *testing.T) {
+	db := newTestDB(t)
+	client := seedClient(t, db, "client@test.fr")
+	lt := NewLoginThrottle(db, 5, 20, 15*time.Minute)
+	req := loginRequest("10.0.0.1")
+	if err := lt.RecordFailure(req, "Client@test.fr"); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	if _, err := lt.ThrottleReset(req, "client@test.fr"); err != nil {
+		t.Fatalf("ThrottleReset: %v", err)
+	}
+
+	if _, err := EraseClient(db, httptest.NewRequest(http.MethodDelete, "/api/me", nil), client.ID); err != nil {
+		t.Fatalf("EraseClient: %v", err)
+	}
+	var kinds []string
+	db.Model(&models.LimiteConnexion{}).Where("value = ?", "client@test.fr").Pluck("kind", &kinds)
+	if len(kinds) != 0 {
+		t.Errorf("compteurs conservés après l'effacement: %v", kinds)
+	}
+	// Les compteurs de l'adresse IP ne désignent pas le client et restent en place
+	var count int64
+	db.Model(&models.LimiteConnexion{}).Where("value = ?", "10.0.0.1").Count(&count)
+	if count != 2 {
+		t.Errorf("%d compteurs de l'adresse IP, attendu 2", count)
+	}
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// profileReadOnlyFields sont les champs du profil que le client ne peut pas modifier lui-même:
+// réservés à l'administration, ou modifiés par une route dédiée (mot de passe, double authentification).
+var profileReadOnlyFields = []string{"ID", "isAdmin", "role", "permissions", "emailVerifie", "totpActive", "motDePasse"}
+
+// ProfileHandler gère le profil du client connecté (/api/me).
+type ProfileHandler struct {
+	DB            *gorm.DB
+	Verifications *VerificationHandler // Envoi du lien de confirmation après un changement d'email
+	Throttle      *LoginThrottle       // Les mots de passe incorrects comptent comme des échecs de connexion
+}
+
+// NewProfileHandler crée une nouvelle instance de ProfileHandler.
+func NewProfileHandler(db *gorm.DB, verifications *VerificationHandler, throttle *LoginThrottle) *ProfileHandler {
+	return &ProfileHandler{DB: db, Verifications: verifications, Throttle: throttle}
+}
+
+// currentClient charge le client authentifié par clientAuthMiddleware.
+func (ph *ProfileHandler) currentClient(w http.ResponseWriter, r *http.Request) (models.Client, bool) {
+	var client models.Client
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return client, false
+	}
+	if err := ph.DB.First(&client, "id = ?", clientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusUnauthorized, ErrAuthInvalid)
+			return client, false
+		}
+		log.Printf("Erreur DB lors de la récupération du client (ID: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return client, false
+	}
+	return client, true
+}
+
+// confirmPassword vérifie le mot de passe actuel saisi par le client et répond en cas d'échec.
+// Les essais sont limités comme à la connexion, pour qu'un jeton volé ne permette pas de deviner le mot de passe.
+func (ph *ProfileHandler) confirmPassword(w http.ResponseWriter, r *http.Request, client models.Client, field, password string) bool {
+	if password == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field(field, FieldRequired))
+		return false
+	}
+	wait, err := ph.Throttle.Check(r, client.Email)
+	if err != nil {
+		log.Printf("Erreur DB lors de la vérification des tentatives (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return false
+	}
+	if wait > 0 {
+		RespondThrottled(w, r, wait)
+		return false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(client.MotDePasseHashed), []byte(password)) != nil {
+		if err := ph.Throttle.RecordFailure(r, client.Email); err != nil {
+			log.Printf("Erreur lors de l'enregistrement de l'échec (client: %s): %v", client.ID, err)
+		}
+		respondWithError(w, r, http.StatusForbidden, ErrCurrentPasswordIncorrect, Field(field, FieldInvalid))
+		return false
+	}
+	return true
+}
+
+// MeHandler lit (GET), modifie (PUT, PATCH) ou supprime (DELETE) le compte du client connecté.
+// Méthodes: GET, PUT, PATCH, DELETE /api/me
+func (ph *ProfileHandler) MeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		client, ok := ph.currentClient(w, r)
+		if !ok {
+			return
+		}
+		respondWithJSON(w, http.StatusOK, Profile(client))
+	case http.MethodPut, http.MethodPatch:
+		ph.updateProfile(w, r)
+	case http.MethodDelete:
+		ph.deleteAccount(w, r)
+	default:
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+	}
+}
+
+// updateProfile modifie les champs envoyés: nomClient, prenomClient, numTel, adresse, langue et email.
+// Changer d'email exige le mot de passe actuel ("motDePasseActuel"); la nouvelle adresse doit être confirmée.
+func (ph *ProfileHandler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	client, ok := ph.currentClient(w, r)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	var raw map[string]json.RawMessage
+	var req struct {
+		NomClient        *string `json:"nomClient"`
+		PrenomClient     *string `json:"prenomClient"`
+		NumTel           *string `json:"numTel"`
+		Adresse          *string `json:"adresse"`
+		Langue           *string `json:"langue"`
+		Email            *string `json:"email"`
+		MotDePasseActuel string  `json:"motDePasseActuel"`
+	}
+	if json.Unmarshal(body, &raw) != nil || json.Unmarshal(body, &req) != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	var details []FieldError
+	for _, field := range profileReadOnlyFields {
+		if _, ok := raw[field]; ok {
+			details = append(details, Field(field, FieldReadOnly))
+		}
+	}
+	updates := map[string]interface{}{}
+	if req.NomClient != nil {
+		if strings.TrimSpace(*req.NomClient) == "" {
+			details = append(details, Field("nomClient", FieldRequired))
+		}
+		updates["nom_client"] = strings.TrimSpace(*req.NomClient)
+	}
+	if req.PrenomClient != nil {
+		if strings.TrimSpace(*req.PrenomClient) == "" {
+			details = append(details, Field("prenomClient", FieldRequired))
+		}
+		updates["prenom_client"] = strings.TrimSpace(*req.PrenomClient)
+	}
+	if req.NumTel != nil {
+		updates["num_tel"] = strings.TrimSpace(*req.NumTel)
+	}
+	if req.Adresse != nil {
+		updates["adresse"] = strings.TrimSpace(*req.Adresse)
+	}
+	newEmail := ""
+	if req.Email != nil {
+		email := strings.TrimSpace(*req.Email)
+		switch {
+		case email == "":
+			details = append(details, Field("email", FieldRequired))
+		case !emailPattern.MatchString(email):
+			details = append(details, Field("email", FieldInvalid))
+		case email != client.Email:
+			newEmail = email
+		}
+	}
+	if len(details) > 0 {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, details...)
+		return
+	}
+	if req.Langue != nil {
+		if !i18n.Supported(*req.Langue) {
+			respondWithError(w, r, http.StatusBadRequest, ErrLanguageUnsupported, Field("langue", FieldInvalid))
+			return
+		}
+		updates["langue"] = *req.Langue
+	}
+
+	if newEmail != "" {
+		if !ph.confirmPassword(w, r, client, "motDePasseActuel", req.MotDePasseActuel) {
+			return
+		}
+		var count int64
+		if err := ph.DB.Model(&models.Client{}).Where("email = ? AND id <> ?", newEmail, client.ID).Count(&count).Error; err != nil {
+			log.Printf("Erreur DB lors de la vérification de l'email (client: %s): %v", client.ID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+			return
+		}
+		if count > 0 {
+			respondWithError(w, r, http.StatusConflict, ErrEmailTaken, Field("email", FieldInvalid))
+			return
+		}
+		updates["email"] = newEmail
+		updates["email_verifie"] = false
+	}
+
+	if len(updates) > 0 {
+		if err := ph.DB.Model(&client).Updates(updates).Error; err != nil {
+			log.Printf("Erreur DB lors de la mise à jour du profil (client: %s): %v", client.ID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+			return
+		}
+		if err := ph.DB.First(&client, "id = ?", client.ID).Error; err != nil {
+			log.Printf("Erreur DB lors de la relecture du profil (client: %s): %v", client.ID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+			return
+		}
+	}
+	if newEmail != "" {
+		// La nouvelle adresse doit être confirmée: les liens envoyés à l'ancienne ne valent plus
+		if err := ph.Verifications.SendVerification(client); err != nil {
+			log.Printf("Erreur lors de l'envoi du lien de confirmation (client: %s): %v", client.ID, err)
+		}
+		log.Printf("Email du client %s modifié, confirmation envoyée à la nouvelle adresse", client.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, Profile(client))
+	log.Printf("Profil mis à jour par le client (ID: %s)", client.ID)
+}
+
+// PasswordHandler change le mot de passe du client connecté, après confirmation du mot de passe actuel.
+// Les autres sessions du client sont révoquées; la session courante reste ouverte.
+// Méthode: POST /api/me/password {"motDePasseActuel": ..., "nouveauMotDePasse": ...}
+func (ph *ProfileHandler) PasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	client, ok := ph.currentClient(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		MotDePasseActuel  string `json:"motDePasseActuel"`
+		NouveauMotDePasse string `json:"nouveauMotDePasse"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	if req.NouveauMotDePasse == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("nouveauMotDePasse", FieldRequired))
+		return
+	}
+	if !ph.confirmPassword(w, r, client, "motDePasseActuel", req.MotDePasseActuel) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NouveauMotDePasse), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Erreur lors du hachage du nouveau mot de passe (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	err = ph.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&client).Update("mot_de_passe_hashed", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		if err := invalidateResetTokens(tx, client.ID); err != nil {
+			return err
+		}
+		return tx.Model(&models.SessionClient{}).
+			Where("client_id = ? AND revoked_at IS NULL AND access_token_hash <> ?", client.ID, hashToken(BearerToken(r))).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		log.Printf("Erreur DB lors du changement de mot de passe (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("Mot de passe changé par le client (ID: %s, IP: %s)", client.ID, clientIP(r))
+}
+
+// deleteAccount supprime le compte du client connecté, après confirmation du mot de passe ({"motDePasse": ...}):
+// ses données personnelles sont effacées (voir EraseClient). Les comptes du personnel doivent d'abord perdre leur rôle.
+func (ph *ProfileHandler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	client, ok := ph.currentClient(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		MotDePasse string `json:"motDePasse"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+			return
+		}
+	}
+	if client.Role != "" {
+		respondWithError(w, r, http.StatusConflict, ErrStaffAccountNotDeletable)
+		return
+	}
+	if !ph.confirmPassword(w, r, client, "motDePasse", req.MotDePasse) {
+		return
+	}
+
+	summary, err := EraseClient(ph.DB, r, client.ID)
+	if err != nil {
+		RespondErasureError(w, r, client.ID, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("Compte supprimé par le client (ID: %s): %d réservation(s) anonymisée(s), %d commande(s) conservée(s)",
+		client.ID, summary.ReservationsAnonymized, summary.OrdersKept)
+}
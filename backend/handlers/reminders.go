@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ReminderScheduler envoie les rappels des réservations confirmées dont le client a demandé un rappel
+// (WantsReminder), à chacun des délais configurés avant l'heure de la réservation (ex: 24h et 2h).
+// Chaque rappel envoyé est enregistré (RappelReservation), pour qu'un redémarrage ne le renvoie pas.
+type ReminderScheduler struct {
+	DB        *gorm.DB
+	Events    *EventBroker
+	LeadTimes []time.Duration // Délais avant la réservation, du plus long au plus court
+	Interval  time.Duration   // Fréquence de vérification
+}
+
+// NewReminderScheduler crée un nouveau planificateur de rappels.
+func NewReminderScheduler(db *gorm.DB, events *EventBroker, leadTimes []time.Duration, interval time.Duration) *ReminderScheduler {
+	leads := append([]time.Duration(nil), leadTimes...)
+	sort.Slice(leads, func(i, j int) bool { return leads[i] > leads[j] })
+	return &ReminderScheduler{DB: db, Events: events, LeadTimes: leads, Interval: interval}
+}
+
+// ParseReminderLeadTimes lit une liste de délais séparés par des virgules (ex: "24h,2h").
+func ParseReminderLeadTimes(value string) ([]time.Duration, error) {
+	var leads []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("délai de rappel trop court: %s", part)
+		}
+		leads = append(leads, d)
+	}
+	return leads, nil
+}
+
+// Run vérifie les rappels à envoyer à chaque intervalle, jusqu'à l'annulation du contexte.
+func (rs *ReminderScheduler) Run(ctx context.Context) {
+	if len(rs.LeadTimes) == 0 {
+		log.Println("Rappels de réservation désactivés (aucun délai configuré).")
+		return
+	}
+	log.Printf("Rappels de réservation actifs (délais: %v, vérification toutes les %v).", rs.LeadTimes, rs.Interval)
+
+	ticker := time.NewTicker(rs.Interval)
+	defer ticker.Stop()
+	for {
+		rs.sendDueReminders(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			log.Println("Rappels de réservation arrêtés.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leadFor renvoie le plus court délai configuré qui couvre l'heure de la réservation.
+func (rs *ReminderScheduler) leadFor(reservation models.Reservation, now time.Time) (time.Duration, bool) {
+	until := reservation.ReservationDate.Sub(now)
+	for i := len(rs.LeadTimes) - 1; i >= 0; i-- {
+		if until <= rs.LeadTimes[i] {
+			return rs.LeadTimes[i], true
+		}
+	}
+	return 0, false
+}
+
+// sendDueReminders envoie les rappels arrivés à échéance.
+// Seul le rappel du plus court délai atteint est envoyé: une réservation confirmée 1h avant
+// ne reçoit que le rappel "2h", et jamais ensuite celui de 24h.
+func (rs *ReminderScheduler) sendDueReminders(ctx context.Context, now time.Time) {
+	var reservations []models.Reservation
+	if err := rs.DB.WithContext(ctx).
+		Where("status = ? AND wants_reminder = ? AND reservation_date > ? AND reservation_date <= ?",
+			"Confirmée", true, now, now.Add(rs.LeadTimes[0])).
+		Find(&reservations).Error; err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Erreur DB lors de la recherche des rappels à envoyer: %v", err)
+		}
+		return
+	}
+
+	for _, reservation := range reservations {
+		if ctx.Err() != nil {
+			return
+		}
+		lead, ok := rs.leadFor(reservation, now)
+		if !ok {
+			continue
+		}
+		sent, err := rs.sendReminder(reservation, lead)
+		if err != nil {
+			log.Printf("Échec de l'envoi du rappel (réservation: %s, délai: %v): %v", reservation.ID, lead, err)
+			continue
+		}
+		if sent {
+			rs.Events.Publish(EventReservationReminder, reservation.ClientID, reservation)
+			log.Printf("Rappel envoyé (réservation: %s, délai: %v)", reservation.ID, lead)
+		}
+	}
+}
+
+// sendReminder enregistre le rappel, crée la notification et met l'email en file dans la même transaction.
+// Renvoie false si un rappel de ce délai (ou d'un délai plus court) a déjà été envoyé.
+func (rs *ReminderScheduler) sendReminder(reservation models.Reservation, lead time.Duration) (bool, error) {
+	leadMinutes := int(lead / time.Minute)
+	sent := false
+	err := rs.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.RappelReservation{}).
+			Where("reservation_id = ? AND lead_minutes <= ?", reservation.ID, leadMinutes).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := tx.Create(&models.RappelReservation{ReservationID: reservation.ID, LeadMinutes: leadMinutes}).Error; err != nil {
+			return err
+		}
+		lang, err := reservationLang(tx, reservation)
+		if err != nil {
+			return err
+		}
+		message, err := renderNotification(tx, i18n.NotifReservationRappel, lang, reservationVars(reservation, lang))
+		if err != nil {
+			return err
+		}
+		if err := CreateNotification(tx, reservation.ClientID, models.NotificationReservation, reservation.ID, message); err != nil {
+			return err
+		}
+		if err := enqueueReservationReminder(tx, reservation, lang); err != nil {
+			return err
+		}
+		sent = true
+		return nil
+	})
+	return sent, err
+}
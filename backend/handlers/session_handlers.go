@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSessionAbsente est renvoyée quand la requête ne porte pas de jeton d'accès.
+	ErrSessionAbsente = errors.New("jeton d'accès manquant")
+	// ErrSessionInvalide est renvoyée pour un jeton inconnu ou révoqué.
+	ErrSessionInvalide = errors.New("jeton invalide ou révoqué")
+	// ErrSessionExpiree est renvoyée pour un jeton d'accès expiré (à rafraîchir).
+	ErrSessionExpiree = errors.New("jeton expiré")
+)
+
+// SessionHandler gère les sessions des clients: jetons d'accès opaques à durée limitée,
+// jetons de rafraîchissement et déconnexion.
+type SessionHandler struct {
+	DB         *gorm.DB
+	AccessTTL  time.Duration // Durée de validité d'un jeton d'accès
+	RefreshTTL time.Duration // Durée de validité d'un jeton de rafraîchissement
+}
+
+// NewSessionHandler crée une nouvelle instance de SessionHandler.
+func NewSessionHandler(db *gorm.DB, accessTTL, refreshTTL time.Duration) *SessionHandler {
+	return &SessionHandler{DB: db, AccessTTL: accessTTL, RefreshTTL: refreshTTL}
+}
+
+// TokenPair est la réponse envoyée à la connexion et au rafraîchissement.
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`         // Secondes avant l'expiration du jeton d'accès
+	RefreshExpiresIn int    `json:"refresh_expires_in"` // Secondes avant l'expiration du jeton de rafraîchissement
+}
+
+// newToken génère un jeton aléatoire de 256 bits, préfixé pour le reconnaître dans les journaux.
+func newToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken renvoie l'empreinte SHA-256 d'un jeton, seule valeur stockée en base.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerToken extrait le jeton de l'en-tête "Authorization: Bearer <jeton>".
+func BearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// clientIP renvoie l'adresse IP de la requête.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// createSession ouvre une nouvelle session pour le client et renvoie ses jetons.
+func (sh *SessionHandler) createSession(tx *gorm.DB, clientID string, r *http.Request) (TokenPair, error) {
+	accessToken, err := newToken("at_")
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refreshToken, err := newToken("rt_")
+	if err != nil {
+		return TokenPair{}, err
+	}
+	now := time.Now()
+	session := models.SessionClient{
+		ClientID:         clientID,
+		AccessTokenHash:  hashToken(accessToken),
+		RefreshTokenHash: hashToken(refreshToken),
+		AccessExpiresAt:  now.Add(sh.AccessTTL),
+		RefreshExpiresAt: now.Add(sh.RefreshTTL),
+		UserAgent:        r.UserAgent(),
+		IP:               clientIP(r),
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(sh.AccessTTL.Seconds()),
+		RefreshExpiresIn: int(sh.RefreshTTL.Seconds()),
+	}, nil
+}
+
+// CreateSession ouvre une nouvelle session après une connexion réussie.
+func (sh *SessionHandler) CreateSession(clientID string, r *http.Request) (TokenPair, error) {
+	return sh.createSession(sh.DB, clientID, r)
+}
+
+// Profile renvoie le profil du compte tel qu'envoyé à l'application (isAdmin, rôle et droits inclus).
+func Profile(client models.Client) map[string]interface{} {
+	return map[string]interface{}{
+		"ID":           client.ID,
+		"email":        client.Email,
+		"nomClient":    client.NomClient,
+		"prenomClient": client.PrenomClient,
+		"numTel":       client.NumTel,
+		"adresse":      client.Adresse,
+		"langue":       client.Langue,
+		"isAdmin":      client.IsAdmin,
+		"emailVerifie": client.EmailVerifie,
+		"totpActive":   client.TOTPActive,
+		"role":         client.Role,
+		"permissions":  RolePermissions(client.Role),
+	}
+}
+
+// LoginResponse renvoie le profil du compte et ses jetons, tels qu'envoyés à l'application après une connexion.
+func LoginResponse(client models.Client, tokens TokenPair) map[string]interface{} {
+	response := Profile(client)
+	response["access_token"] = tokens.AccessToken
+	response["refresh_token"] = tokens.RefreshToken
+	response["token_type"] = tokens.TokenType
+	response["expires_in"] = tokens.ExpiresIn
+	response["refresh_expires_in"] = tokens.RefreshExpiresIn
+	return response
+}
+
+// Authenticate vérifie le jeton d'accès de la requête et renvoie la session correspondante.
+func (sh *SessionHandler) Authenticate(r *http.Request) (models.SessionClient, error) {
+	var session models.SessionClient
+	token := BearerToken(r)
+	if token == "" {
+		return session, ErrSessionAbsente
+	}
+	if err := sh.DB.Where("access_token_hash = ?", hashToken(token)).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, ErrSessionInvalide
+		}
+		return session, err
+	}
+	if session.RevokedAt != nil {
+		return session, ErrSessionInvalide
+	}
+	if time.Now().After(session.AccessExpiresAt) {
+		return session, ErrSessionExpiree
+	}
+	return session, nil
+}
+
+// RespondAuthError envoie l'erreur correspondant à un échec d'Authenticate.
+func RespondAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrSessionAbsente):
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+	case errors.Is(err, ErrSessionExpiree):
+		respondWithError(w, r, http.StatusUnauthorized, ErrSessionExpired)
+	case errors.Is(err, ErrSessionInvalide):
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthInvalid)
+	default:
+		log.Printf("Erreur DB lors de la vérification de la session: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+	}
+}
+
+// revokeClientSessions révoque toutes les sessions ouvertes d'un client.
+func revokeClientSessions(tx *gorm.DB, clientID string) error {
+	return tx.Model(&models.SessionClient{}).
+		Where("client_id = ? AND revoked_at IS NULL", clientID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RefreshHandler échange un jeton de rafraîchissement contre une nouvelle paire de jetons.
+// L'ancienne session est révoquée (rotation): un jeton de rafraîchissement ne sert qu'une fois.
+// Si un jeton déjà utilisé est présenté à nouveau (jeton volé), toutes les sessions du client sont révoquées.
+// Méthode: POST /auth/refresh
+func (sh *SessionHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	if req.RefreshToken == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("refresh_token", FieldRequired))
+		return
+	}
+
+	var tokens TokenPair
+	var reused bool
+	err := sh.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.SessionClient
+		if err := tx.Where("refresh_token_hash = ?", hashToken(req.RefreshToken)).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionInvalide
+			}
+			return err
+		}
+		if session.RevokedAt != nil {
+			reused = true
+			return revokeClientSessions(tx, session.ClientID)
+		}
+		if time.Now().After(session.RefreshExpiresAt) {
+			return ErrSessionExpiree
+		}
+
+		now := time.Now()
+		if err := tx.Model(&session).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		var err error
+		tokens, err = sh.createSession(tx, session.ClientID, r)
+		return err
+	})
+	if err == nil && reused {
+		log.Printf("Jeton de rafraîchissement réutilisé: toutes les sessions du client sont révoquées (IP: %s)", clientIP(r))
+		err = ErrSessionInvalide
+	}
+	if err != nil {
+		if errors.Is(err, ErrSessionExpiree) {
+			// Un jeton de rafraîchissement expiré impose de se reconnecter
+			respondWithError(w, r, http.StatusUnauthorized, ErrAuthInvalid)
+			return
+		}
+		RespondAuthError(w, r, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+// LogoutHandler révoque la session courante, ou toutes les sessions du client avec {"all": true}.
+// Méthode: POST /auth/logout
+func (sh *SessionHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	session, err := sh.Authenticate(r)
+	if err != nil && !errors.Is(err, ErrSessionExpiree) { // Un jeton expiré peut quand même fermer sa session
+		RespondAuthError(w, r, err)
+		return
+	}
+	var req struct {
+		All bool `json:"all"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+			return
+		}
+	}
+
+	if req.All {
+		err = revokeClientSessions(sh.DB, session.ClientID)
+	} else {
+		err = sh.DB.Model(&session).Update("revoked_at", time.Now()).Error
+	}
+	if err != nil {
+		log.Printf("Erreur DB lors de la déconnexion (client: %s): %v", session.ClientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("Client déconnecté (client: %s, toutes les sessions: %v)", session.ClientID, req.All)
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Droits vérifiés sur les routes du personnel (/admin/*, /kitchen/*)
+const (
+	PermReservations = "reservations" // Consulter et gérer les réservations
+	PermOrders       = "orders"       // Consulter les commandes et changer leur statut
+	PermKitchen      = "kitchen"      // Écran cuisine (tickets, postes)
+	PermMenu         = "menu"         // Créer, modifier et supprimer des plats
+	PermPayments     = "payments"     // Consulter et encaisser les paiements
+	PermRefunds      = "refunds"      // Rembourser un paiement
+	PermClients      = "clients"      // Gérer les comptes clients
+	PermTemplates    = "templates"    // Modifier les textes des notifications, emails et SMS
+	PermEvents       = "events"       // Flux temps réel de l'administration
+	PermStaff        = "staff"        // Attribuer et retirer les rôles du personnel
+	PermAudit        = "audit"        // Consulter le journal des modifications faites depuis l'administration
+)
+
+// rolePermissions liste les droits de chaque rôle du personnel.
+var rolePermissions = map[string][]string{
+	models.RoleOwner: {PermReservations, PermOrders, PermKitchen, PermMenu, PermPayments, PermRefunds,
+		PermClients, PermTemplates, PermEvents, PermStaff, PermAudit},
+	models.RoleManager: {PermReservations, PermOrders, PermKitchen, PermMenu, PermPayments, PermRefunds,
+		PermClients, PermTemplates, PermEvents, PermAudit},
+	models.RoleWaiter:  {PermReservations, PermOrders, PermEvents},
+	models.RoleChef:    {PermKitchen, PermOrders, PermMenu, PermEvents},
+	models.RoleCashier: {PermPayments, PermOrders, PermEvents},
+}
+
+// roleOrder fixe l'ordre d'affichage des rôles.
+var roleOrder = []string{models.RoleOwner, models.RoleManager, models.RoleWaiter, models.RoleChef, models.RoleCashier}
+
+// ValidRole indique si le rôle fait partie des rôles du personnel.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions renvoie les droits d'un rôle (aucun pour un client).
+func RolePermissions(role string) []string {
+	if perms, ok := rolePermissions[role]; ok {
+		return perms
+	}
+	return []string{}
+}
+
+// HasPermission indique si le rôle dispose du droit demandé.
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrPersonnelRequis est renvoyée quand le compte authentifié n'a pas de rôle du personnel.
+var ErrPersonnelRequis = errors.New("compte sans rôle du personnel")
+
+// PermissionError est renvoyée quand le rôle du compte ne dispose pas du droit demandé.
+type PermissionError struct {
+	Role       string
+	Permission string
+}
+
+func (e *PermissionError) Error() string {
+	return "le rôle " + e.Role + " n'a pas le droit " + e.Permission
+}
+
+// AuthorizeStaff charge le compte du membre du personnel et vérifie son droit.
+// Le rôle est relu à chaque requête: un retrait de rôle prend effet immédiatement.
+func AuthorizeStaff(db *gorm.DB, clientID, permission string) (models.Client, error) {
+	var staff models.Client
+	if err := db.First(&staff, "id = ?", clientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return staff, ErrSessionInvalide
+		}
+		return staff, err
+	}
+	if !ValidRole(staff.Role) {
+		return staff, ErrPersonnelRequis
+	}
+	if !HasPermission(staff.Role, permission) {
+		return staff, &PermissionError{Role: staff.Role, Permission: permission}
+	}
+	return staff, nil
+}
+
+// RespondStaffError envoie l'erreur correspondant à un échec d'AuthorizeStaff.
+func RespondStaffError(w http.ResponseWriter, r *http.Request, err error) {
+	var permErr *PermissionError
+	switch {
+	case errors.Is(err, ErrPersonnelRequis):
+		respondWithError(w, r, http.StatusForbidden, ErrAdminRequired)
+	case errors.Is(err, ErrDoubleAuthRequise):
+		respondWithError(w, r, http.StatusForbidden, ErrTwoFactorRequired)
+	case errors.As(err, &permErr):
+		respondWithErrorParams(w, r, http.StatusForbidden, ErrPermissionDenied,
+			map[string]string{"role": permErr.Role, "permission": permErr.Permission})
+	default:
+		RespondAuthError(w, r, err)
+	}
+}
+
+// StaffHandler regroupe les dépendances et les méthodes de gestion des rôles du personnel.
+type StaffHandler struct {
+	DB *gorm.DB
+}
+
+// NewStaffHandler crée une nouvelle instance de StaffHandler.
+func NewStaffHandler(db *gorm.DB) *StaffHandler {
+	return &StaffHandler{DB: db}
+}
+
+// StaffMember est un membre du personnel et ses droits.
+type StaffMember struct {
+	ID           string   `json:"ID"`
+	Email        string   `json:"email"`
+	NomClient    string   `json:"nomClient"`
+	PrenomClient string   `json:"prenomClient"`
+	Role         string   `json:"role"`
+	Permissions  []string `json:"permissions"`
+}
+
+func newStaffMember(c models.Client) StaffMember {
+	return StaffMember{
+		ID:           c.ID,
+		Email:        c.Email,
+		NomClient:    c.NomClient,
+		PrenomClient: c.PrenomClient,
+		Role:         c.Role,
+		Permissions:  RolePermissions(c.Role),
+	}
+}
+
+// RoleView décrit un rôle et ses droits.
+type RoleView struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// ListRolesHandler renvoie les rôles disponibles et leurs droits.
+// Méthode: GET /admin/roles
+func (sh *StaffHandler) ListRolesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	roles := make([]RoleView, 0, len(roleOrder))
+	for _, role := range roleOrder {
+		roles = append(roles, RoleView{Role: role, Permissions: RolePermissions(role)})
+	}
+	respondWithJSON(w, http.StatusOK, roles)
+}
+
+// ListStaffHandler renvoie les comptes ayant un rôle du personnel.
+// Méthode: GET /admin/staff
+func (sh *StaffHandler) ListStaffHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	var clients []models.Client
+	if err := sh.DB.Where("role <> ''").Order("role ASC, nom_client ASC").Find(&clients).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération du personnel: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	staff := make([]StaffMember, 0, len(clients))
+	for _, c := range clients {
+		staff = append(staff, newStaffMember(c))
+	}
+	respondWithJSON(w, http.StatusOK, staff)
+}
+
+// errDernierProprietaire est renvoyée quand le changement retirerait le dernier propriétaire.
+var errDernierProprietaire = errors.New("dernier propriétaire")
+
+// setRole attribue un rôle à un compte (vide pour retirer l'accès du personnel).
+// Quand l'accès est retiré, les sessions ouvertes du compte sont révoquées.
+// Le changement est tracé dans le journal d'audit, dans la même transaction. Le compte est renvoyé à jour.
+func (sh *StaffHandler) setRole(r *http.Request, clientID, role string) (client models.Client, err error) {
+	err = sh.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&client, "id = ?", clientID).Error; err != nil {
+			return err
+		}
+		before := client
+		if client.Role == models.RoleOwner && role != models.RoleOwner {
+			var owners int64
+			if err := tx.Model(&models.Client{}).Where("role = ?", models.RoleOwner).Count(&owners).Error; err != nil {
+				return err
+			}
+			if owners <= 1 {
+				return errDernierProprietaire
+			}
+		}
+		if err := tx.Model(&client).Updates(map[string]interface{}{"role": role, "is_admin": role != ""}).Error; err != nil {
+			return err
+		}
+		client.Role, client.IsAdmin = role, role != ""
+		if role == "" {
+			if err := revokeClientSessions(tx, client.ID); err != nil {
+				return err
+			}
+		}
+		return RecordAudit(tx, r, AuditRoleChange, AuditClient, client.ID, ClientAuditView(before), ClientAuditView(client))
+	})
+	return client, err
+}
+
+// ItemHandler attribue un rôle (PUT {"role": "chef"}) ou retire l'accès du personnel (DELETE)
+// au compte /admin/staff/{id}. Le compte doit déjà exister (inscription ou création par un admin).
+// Méthodes: PUT, DELETE
+func (sh *StaffHandler) ItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Attendu: ["", "admin", "staff", id]
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[3] == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	clientID := parts[3]
+
+	var role string
+	switch r.Method {
+	case http.MethodPut:
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+			return
+		}
+		role = strings.ToLower(strings.TrimSpace(req.Role))
+		if role != "" && !ValidRole(role) {
+			respondWithError(w, r, http.StatusBadRequest, ErrRoleInvalid, Field("role", FieldInvalid))
+			return
+		}
+	case http.MethodDelete:
+		role = ""
+	default:
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	actor := ActorFromContext(r.Context())
+	client, err := sh.setRole(r, clientID, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondWithError(w, r, http.StatusNotFound, ErrClientNotFound)
+		case errors.Is(err, errDernierProprietaire):
+			respondWithError(w, r, http.StatusConflict, ErrLastOwner)
+		default:
+			log.Printf("Erreur DB lors du changement de rôle (client: %s): %v", clientID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		}
+		return
+	}
+
+	log.Printf("Rôle du compte %s changé en '%s' par %s (%s)", client.Email, role, actor.ID, actor.Role)
+	if role == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newStaffMember(client))
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// TemplateHandler regroupe les méthodes de modification des textes envoyés aux clients.
+type TemplateHandler struct {
+	DB *gorm.DB
+}
+
+// NewTemplateHandler crée une nouvelle instance de TemplateHandler.
+func NewTemplateHandler(db *gorm.DB) *TemplateHandler {
+	return &TemplateHandler{DB: db}
+}
+
+// TemplateView est un modèle de message avec sa description et ses variables disponibles.
+type TemplateView struct {
+	Key         string                 `json:"key"`
+	Lang        string                 `json:"lang"`
+	Description string                 `json:"description"`
+	Variables   map[string]interface{} `json:"variables"` // Variables disponibles, avec un exemple de valeur
+	Subject     string                 `json:"subject"`
+	Body        string                 `json:"body"`
+	Modified    bool                   `json:"modified"` // Différent du texte par défaut
+}
+
+// templateRequest est le corps attendu pour modifier un modèle.
+type templateRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// buildTemplateView construit la vue d'un modèle enregistré.
+func buildTemplateView(m models.ModeleMessage) TemplateView {
+	def := i18n.Definitions[m.Key]
+	defaults := def.Defaults[m.Lang]
+	return TemplateView{
+		Key:         m.Key,
+		Lang:        m.Lang,
+		Description: def.Description,
+		Variables:   def.Variables,
+		Subject:     m.Subject,
+		Body:        m.Body,
+		Modified:    m.Subject != defaults.Subject || m.Body != defaults.Body,
+	}
+}
+
+// ListTemplatesHandler renvoie tous les modèles de messages, filtre optionnel par langue.
+// Méthode: GET /admin/templates?lang={fr|en}
+func (th *TemplateHandler) ListTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	query := th.DB.Order("key ASC, lang ASC")
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		query = query.Where("lang = ?", lang)
+	}
+	var modeles []models.ModeleMessage
+	if err := query.Find(&modeles).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération des modèles de messages: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	views := make([]TemplateView, 0, len(modeles))
+	for _, m := range modeles {
+		if _, ok := i18n.Definitions[m.Key]; ok {
+			views = append(views, buildTemplateView(m))
+		}
+	}
+	sort.SliceStable(views, func(i, j int) bool { return views[i].Key < views[j].Key })
+	respondWithJSON(w, http.StatusOK, views)
+}
+
+// ItemHandler gère un modèle: GET pour le lire, PUT pour modifier son texte,
+// DELETE pour revenir au texte par défaut.
+// Méthode: GET, PUT, DELETE /admin/templates/{key}/{lang}
+func (th *TemplateHandler) ItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Attendu: ["", "admin", "templates", key, lang]
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) != 5 || parts[3] == "" || parts[4] == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	key, lang := parts[3], parts[4]
+	def, ok := i18n.Definitions[key]
+	if !ok || !i18n.Supported(lang) {
+		respondWithError(w, r, http.StatusNotFound, ErrTemplateNotFound)
+		return
+	}
+
+	var modele models.ModeleMessage
+	err := th.DB.Where("key = ? AND lang = ?", key, lang).First(&modele).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		defaults := def.Defaults[lang]
+		modele = models.ModeleMessage{Key: key, Lang: lang, Subject: defaults.Subject, Body: defaults.Body}
+	} else if err != nil {
+		log.Printf("Erreur DB lors de la récupération du modèle '%s' (%s): %v", key, lang, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	before := map[string]interface{}{"subject": modele.Subject, "body": modele.Body}
+	switch r.Method {
+	case http.MethodGet:
+		respondWithJSON(w, http.StatusOK, buildTemplateView(modele))
+		return
+	case http.MethodPut:
+		var req templateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+			return
+		}
+		if strings.TrimSpace(req.Body) == "" {
+			respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("body", FieldRequired))
+			return
+		}
+		if err := i18n.Validate(key, i18n.Text{Subject: req.Subject, Body: req.Body}); err != nil {
+			respondWithErrorParams(w, r, http.StatusBadRequest, ErrTemplateInvalid, map[string]string{"error": err.Error()})
+			return
+		}
+		modele.Subject, modele.Body = req.Subject, req.Body
+	case http.MethodDelete:
+		defaults := def.Defaults[lang]
+		modele.Subject, modele.Body = defaults.Subject, defaults.Body
+	default:
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+
+	err = th.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&modele).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, r, AuditUpdate, AuditModele, key+"/"+lang,
+			before, map[string]interface{}{"subject": modele.Subject, "body": modele.Body})
+	})
+	if err != nil {
+		log.Printf("Erreur DB lors de l'enregistrement du modèle '%s' (%s): %v", key, lang, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, buildTemplateView(modele))
+	log.Printf("Modèle de message '%s' (%s) mis à jour", key, lang)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB ouvre une base SQLite propre au test, avec toutes les tables de l'application.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("ouverture de la base de test: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Client{}, &models.SessionClient{}, &models.InvitationPersonnel{},
+		&models.JetonReinitialisation{}, &models.JetonVerification{}, &models.LimiteConnexion{},
+		&models.JournalAudit{}, &models.CodeSecours{}, &models.DefiConnexion{},
+		&models.Plat{}, &models.Panier{}, &models.Reservation{}, &models.RappelReservation{},
+		&models.Commande{}, &models.Paiement{}, &models.Remboursement{}, &models.EvenementWebhook{},
+		&models.Notification{}, &models.MessageSortant{}, &models.ModeleMessage{},
+		&models.CommandePlat{}, &models.CommandeStatutHistorique{}, &models.PanierPlat{},
+	); err != nil {
+		t.Fatalf("migration de la base de test: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// seedClient crée un compte client de test.
+func seedClient(t *testing.T, db *gorm.DB, email string) models.Client {
+	t.Helper()
+	client := models.Client{Email: email, NomClient: "Test", PrenomClient: "Client", Langue: "fr", EmailVerifie: true}
+	if err := db.Create(&client).Error; err != nil {
+		t.Fatalf("création du client %s: %v", email, err)
+	}
+	return client
+}
+
+// seedCommande crée une commande du client, au statut et au montant donnés.
+func seedCommande(t *testing.T, db *gorm.DB, clientID, status string, total, paid float64) models.Commande {
+	t.Helper()
+	commande := models.Commande{ClientID: clientID, Status: status, TotalAmount: total, AmountPaid: paid}
+	if err := db.Create(&commande).Error; err != nil {
+		t.Fatalf("création de la commande: %v", err)
+	}
+	return commande
+}
@@ -0,0 +1,555 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/models"
+	"restaurant-app/backend/totp"
+
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute // Délai pour saisir le code après le mot de passe
+	twoFactorMaxAttempts  = 5               // Codes incorrects acceptés pour une même connexion
+	twoFactorSkew         = 1               // Pas de temps d'écart tolérés (décalage d'horloge du téléphone)
+	recoveryCodeCount     = 10              // Codes de secours générés à l'activation
+)
+
+var (
+	// ErrDoubleAuthRequise est renvoyée quand la politique impose la double authentification
+	// à un membre du personnel qui ne l'a pas activée.
+	ErrDoubleAuthRequise = errors.New("double authentification requise pour le personnel")
+	// errCodeInvalide est renvoyée pour un code TOTP ou de secours incorrect ou déjà utilisé.
+	errCodeInvalide = errors.New("code de double authentification invalide")
+	// errDefiInvalide est renvoyée pour un défi de connexion inconnu, terminé ou expiré.
+	errDefiInvalide = errors.New("défi de connexion invalide ou expiré")
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorHandler gère la double authentification (TOTP, RFC 6238): activation, codes de secours
+// et seconde étape de la connexion.
+type TwoFactorHandler struct {
+	DB            *gorm.DB
+	Sessions      *SessionHandler
+	Throttle      *LoginThrottle
+	Issuer        string // Nom affiché dans l'application d'authentification
+	StaffRequired bool   // Politique: double authentification obligatoire pour les rôles du personnel
+}
+
+// NewTwoFactorHandler crée une nouvelle instance de TwoFactorHandler.
+func NewTwoFactorHandler(db *gorm.DB, sessions *SessionHandler, throttle *LoginThrottle, issuer string, staffRequired bool) *TwoFactorHandler {
+	return &TwoFactorHandler{DB: db, Sessions: sessions, Throttle: throttle, Issuer: issuer, StaffRequired: staffRequired}
+}
+
+// Required indique si la politique impose la double authentification à ce compte.
+func (th *TwoFactorHandler) Required(client models.Client) bool {
+	return th.StaffRequired && ValidRole(client.Role)
+}
+
+// RequireEnrolled renvoie ErrDoubleAuthRequise si le membre du personnel doit activer
+// la double authentification avant d'accéder à l'administration.
+func (th *TwoFactorHandler) RequireEnrolled(staff models.Client) error {
+	if th.Required(staff) && !staff.TOTPActive {
+		return ErrDoubleAuthRequise
+	}
+	return nil
+}
+
+// StartChallenge crée le défi de connexion d'un compte dont le mot de passe est correct
+// et renvoie la réponse de /login: le jeton du défi est à envoyer avec le code sur /login/2fa.
+func (th *TwoFactorHandler) StartChallenge(client models.Client, r *http.Request) (map[string]interface{}, error) {
+	token, err := newToken("mfa_")
+	if err != nil {
+		return nil, err
+	}
+	defi := models.DefiConnexion{
+		ClientID:  client.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(twoFactorChallengeTTL),
+		IP:        clientIP(r),
+	}
+	if err := th.DB.Create(&defi).Error; err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"twoFactorRequired": true,
+		"mfa_token":         token,
+		"expires_in":        int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// normalizeRecoveryCode met un code de secours sous la forme utilisée pour son empreinte.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// generateRecoveryCodes remplace les codes de secours du client et les renvoie en clair (seule fois où ils sont visibles).
+func generateRecoveryCodes(tx *gorm.DB, clientID string) ([]string, error) {
+	if err := tx.Where("client_id = ?", clientID).Delete(&models.CodeSecours{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b)) // 10 caractères
+		code := raw[:5] + "-" + raw[5:]
+		if err := tx.Create(&models.CodeSecours{ClientID: clientID, CodeHash: hashToken(raw)}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// verifySecondFactor accepte un code de l'application d'authentification ou, si allowRecovery,
+// un code de secours. Chaque code ne sert qu'une fois. recovery vaut true si un code de secours a été utilisé.
+func verifySecondFactor(tx *gorm.DB, client models.Client, code string, allowRecovery bool) (recovery bool, err error) {
+	code = strings.TrimSpace(code)
+	if code == "" || client.TOTPSecret == "" {
+		return false, errCodeInvalide
+	}
+	if step, ok := totp.Validate(client.TOTPSecret, code, time.Now(), twoFactorSkew); ok {
+		// Mise à jour conditionnelle: un code déjà accepté (ou plus ancien) est refusé
+		result := tx.Model(&models.Client{}).
+			Where("id = ? AND totp_last_step < ?", client.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return false, errCodeInvalide
+		}
+		return false, nil
+	}
+	if !allowRecovery {
+		return false, errCodeInvalide
+	}
+	result := tx.Model(&models.CodeSecours{}).
+		Where("client_id = ? AND code_hash = ? AND used_at IS NULL", client.ID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, errCodeInvalide
+	}
+	return true, nil
+}
+
+// checkCode vérifie le code saisi par un client connecté et répond en cas d'échec.
+// Les codes incorrects comptent comme des échecs de connexion (limitation par email et par IP).
+func (th *TwoFactorHandler) checkCode(w http.ResponseWriter, r *http.Request, client models.Client, code string, allowRecovery bool) bool {
+	if strings.TrimSpace(code) == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("code", FieldRequired))
+		return false
+	}
+	wait, err := th.Throttle.Check(r, client.Email)
+	if err != nil {
+		log.Printf("Erreur DB lors de la vérification des tentatives (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return false
+	}
+	if wait > 0 {
+		RespondThrottled(w, r, wait)
+		return false
+	}
+	if _, err := verifySecondFactor(th.DB, client, code, allowRecovery); err != nil {
+		if errors.Is(err, errCodeInvalide) {
+			if err := th.Throttle.RecordFailure(r, client.Email); err != nil {
+				log.Printf("Erreur lors de l'enregistrement de l'échec (client: %s): %v", client.ID, err)
+			}
+			respondWithError(w, r, http.StatusBadRequest, ErrTwoFactorCodeInvalid, Field("code", FieldInvalid))
+			return false
+		}
+		log.Printf("Erreur DB lors de la vérification du code (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return false
+	}
+	return true
+}
+
+// currentClient charge le client authentifié par clientAuthMiddleware.
+func (th *TwoFactorHandler) currentClient(w http.ResponseWriter, r *http.Request) (models.Client, bool) {
+	var client models.Client
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return client, false
+	}
+	if err := th.DB.First(&client, "id = ?", clientID).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération du client (ID: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return client, false
+	}
+	return client, true
+}
+
+// decodeCode lit le corps {"code": ...} des requêtes de double authentification.
+func decodeCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return "", false
+	}
+	return req.Code, true
+}
+
+// StatusHandler indique si la double authentification est activée et si elle est obligatoire pour le compte.
+// Méthode: GET /auth/2fa
+func (th *TwoFactorHandler) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	client, ok := th.currentClient(w, r)
+	if !ok {
+		return
+	}
+	var remaining int64
+	if err := th.DB.Model(&models.CodeSecours{}).
+		Where("client_id = ? AND used_at IS NULL", client.ID).Count(&remaining).Error; err != nil {
+		log.Printf("Erreur DB lors du comptage des codes de secours (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":                client.TOTPActive,
+		"required":               th.Required(client),
+		"recoveryCodesRemaining": remaining,
+	})
+}
+
+// SetupHandler génère un nouveau secret et renvoie l'adresse otpauth:// à scanner.
+// La double authentification n'est active qu'après confirmation d'un code (/auth/2fa/enable).
+// Méthode: POST /auth/2fa/setup
+func (th *TwoFactorHandler) SetupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	client, ok := th.currentClient(w, r)
+	if !ok {
+		return
+	}
+	if client.TOTPActive {
+		respondWithError(w, r, http.StatusConflict, ErrTwoFactorEnabled)
+		return
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Erreur lors de la génération du secret TOTP: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	if err := th.DB.Model(&client).Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+		log.Printf("Erreur DB lors de l'enregistrement du secret TOTP (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"secret":      secret,
+		"otpauth_uri": totp.URI(th.Issuer, client.Email, secret),
+		"digits":      totp.Digits,
+		"period":      totp.Period,
+	})
+}
+
+// EnableHandler active la double authentification après vérification d'un premier code,
+// et renvoie les codes de secours (affichés une seule fois).
+// Méthode: POST /auth/2fa/enable {"code": "123456"}
+func (th *TwoFactorHandler) EnableHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	client, ok := th.currentClient(w, r)
+	if !ok {
+		return
+	}
+	code, ok := decodeCode(w, r)
+	if !ok {
+		return
+	}
+	if client.TOTPActive {
+		respondWithError(w, r, http.StatusConflict, ErrTwoFactorEnabled)
+		return
+	}
+	if client.TOTPSecret == "" {
+		respondWithError(w, r, http.StatusConflict, ErrTwoFactorNotSetUp)
+		return
+	}
+	if !th.checkCode(w, r, client, code, false) {
+		return
+	}
+
+	var codes []string
+	err := th.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&client).Update("totp_active", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = generateRecoveryCodes(tx, client.ID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Erreur DB lors de l'activation de la double authentification (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"enabled": true, "recoveryCodes": codes})
+	log.Printf("Double authentification activée (client: %s)", client.ID)
+}
+
+// DisableHandler désactive la double authentification (code TOTP ou de secours exigé).
+// Impossible pour le personnel quand la politique la rend obligatoire.
+// Méthode: POST /auth/2fa/disable {"code": ...}
+func (th *TwoFactorHandler) DisableHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	client, ok := th.currentClient(w, r)
+	if !ok {
+		return
+	}
+	code, ok := decodeCode(w, r)
+	if !ok {
+		return
+	}
+	if !client.TOTPActive {
+		respondWithError(w, r, http.StatusConflict, ErrTwoFactorNotEnabled)
+		return
+	}
+	if th.Required(client) {
+		respondWithError(w, r, http.StatusConflict, ErrTwoFactorMandatory)
+		return
+	}
+	if !th.checkCode(w, r, client, code, true) {
+		return
+	}
+	if err := disableTwoFactor(th.DB, client.ID); err != nil {
+		log.Printf("Erreur DB lors de la désactivation de la double authentification (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("Double authentification désactivée (client: %s)", client.ID)
+}
+
+// disableTwoFactor efface le secret et les codes de secours d'un compte.
+func disableTwoFactor(db *gorm.DB, clientID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Client{}).Where("id = ?", clientID).Updates(map[string]interface{}{
+			"totp_secret": "", "totp_active": false, "totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("client_id = ?", clientID).Delete(&models.CodeSecours{}).Error
+	})
+}
+
+// RecoveryCodesHandler remplace les codes de secours (code TOTP ou de secours exigé).
+// Méthode: POST /auth/2fa/recovery-codes {"code": ...}
+func (th *TwoFactorHandler) RecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	client, ok := th.currentClient(w, r)
+	if !ok {
+		return
+	}
+	code, ok := decodeCode(w, r)
+	if !ok {
+		return
+	}
+	if !client.TOTPActive {
+		respondWithError(w, r, http.StatusConflict, ErrTwoFactorNotEnabled)
+		return
+	}
+	if !th.checkCode(w, r, client, code, true) {
+		return
+	}
+	var codes []string
+	err := th.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = generateRecoveryCodes(tx, client.ID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Erreur DB lors du renouvellement des codes de secours (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"recoveryCodes": codes})
+}
+
+// LoginHandler termine une connexion en attente du second facteur: le jeton renvoyé par /login
+// et un code TOTP (ou de secours) sont échangés contre une session.
+// Méthode: POST /login/2fa {"mfa_token": ..., "code": ...}
+func (th *TwoFactorHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	var req struct {
+		MfaToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+	var details []FieldError
+	if strings.TrimSpace(req.MfaToken) == "" {
+		details = append(details, Field("mfa_token", FieldRequired))
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		details = append(details, Field("code", FieldRequired))
+	}
+	if len(details) > 0 {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, details...)
+		return
+	}
+
+	var defi models.DefiConnexion
+	var client models.Client
+	err := th.DB.Where("token_hash = ?", hashToken(strings.TrimSpace(req.MfaToken))).First(&defi).Error
+	if err == nil {
+		if defi.UsedAt != nil || time.Now().After(defi.ExpiresAt) || defi.Attempts >= twoFactorMaxAttempts {
+			err = errDefiInvalide
+		} else {
+			err = th.DB.First(&client, "id = ?", defi.ClientID).Error
+		}
+	}
+	if err != nil {
+		if errors.Is(err, errDefiInvalide) || errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusUnauthorized, ErrTwoFactorChallenge)
+			return
+		}
+		log.Printf("Erreur DB lors de la récupération du défi de connexion: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	wait, err := th.Throttle.Check(r, client.Email)
+	if err != nil {
+		log.Printf("Erreur DB lors de la vérification des tentatives (client: %s): %v", client.ID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	if wait > 0 {
+		RespondThrottled(w, r, wait)
+		return
+	}
+
+	var tokens TokenPair
+	var recovery bool
+	err = th.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if recovery, err = verifySecondFactor(tx, client, req.Code, true); err != nil {
+			return err
+		}
+		// Termine le défi sous condition, pour qu'une requête concurrente ne puisse pas le réutiliser
+		result := tx.Model(&models.DefiConnexion{}).
+			Where("id = ? AND used_at IS NULL", defi.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errDefiInvalide
+		}
+		tokens, err = th.Sessions.createSession(tx, client.ID, r)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errCodeInvalide):
+			if err := th.DB.Model(&defi).Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+				log.Printf("Erreur DB lors du comptage des essais (défi: %s): %v", defi.ID, err)
+			}
+			if err := th.Throttle.RecordFailure(r, client.Email); err != nil {
+				log.Printf("Erreur lors de l'enregistrement de l'échec (client: %s): %v", client.ID, err)
+			}
+			respondWithError(w, r, http.StatusUnauthorized, ErrTwoFactorCodeInvalid, Field("code", FieldInvalid))
+		case errors.Is(err, errDefiInvalide):
+			respondWithError(w, r, http.StatusUnauthorized, ErrTwoFactorChallenge)
+		default:
+			log.Printf("Erreur DB lors de la connexion à deux facteurs (client: %s): %v", client.ID, err)
+			respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		}
+		return
+	}
+
+	if err := th.Throttle.RecordSuccess(client.Email); err != nil {
+		log.Printf("Erreur lors de la remise à zéro des échecs de connexion (client: %s): %v", client.ID, err)
+	}
+	respondWithJSON(w, http.StatusOK, LoginResponse(client, tokens))
+	log.Printf("Connexion à deux facteurs réussie (client: %s, code de secours: %v)", client.ID, recovery)
+}
+
+// ResetHandler désactive la double authentification d'un membre du personnel (téléphone perdu
+// et codes de secours épuisés). Ses sessions sont révoquées; il devra la réactiver si elle est obligatoire.
+// Méthode: DELETE /admin/staff/{id}/2fa
+func (th *TwoFactorHandler) ResetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	// Attendu: ["", "admin", "staff", id, "2fa"]
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) != 5 || parts[3] == "" || parts[4] != "2fa" {
+		respondWithError(w, r, http.StatusBadRequest, ErrInvalidURL)
+		return
+	}
+	clientID := parts[3]
+
+	var client models.Client
+	if err := th.DB.First(&client, "id = ?", clientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, r, http.StatusNotFound, ErrClientNotFound)
+			return
+		}
+		log.Printf("Erreur DB lors de la récupération du client (ID: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	if !client.TOTPActive && client.TOTPSecret == "" {
+		respondWithError(w, r, http.StatusConflict, ErrTwoFactorNotEnabled)
+		return
+	}
+	err := th.DB.Transaction(func(tx *gorm.DB) error {
+		if err := disableTwoFactor(tx, client.ID); err != nil {
+			return err
+		}
+		if err := revokeClientSessions(tx, client.ID); err != nil {
+			return err
+		}
+		after := client
+		after.TOTPActive, after.TOTPSecret = false, ""
+		return RecordAudit(tx, r, AuditTwoFactorReset, AuditClient, client.ID, ClientAuditView(client), ClientAuditView(after))
+	})
+	if err != nil {
+		log.Printf("Erreur DB lors de la réinitialisation de la double authentification (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	actor := ActorFromContext(r.Context())
+	log.Printf("Double authentification réinitialisée pour %s par %s (%s)", client.Email, actor.ID, actor.Role)
+}
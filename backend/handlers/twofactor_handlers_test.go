@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"restaurant-app/backend/models"
+	"restaurant-app/backend/totp"
+
+	"gorm.io/gorm"
+)
+
+// seedTwoFactorClient crée un client dont la double authentification est active.
+func seedTwoFactorClient(t *testing.T, db *gorm.DB, email string) models.Client {
+	t.Helper()
+	client := seedClient(t, db, email)
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	if err := db.Model(&client).Updates(map[string]interface{}{"totp_secret": secret, "totp_active": true}).Error; err != nil {
+		t.Fatalf("activation de la double authentification: %v", err)
+	}
+	client.TOTPSecret, client.TOTPActive = secret, true
+	return client
+}
+
+// totpCode renvoie le code de l'application d'authentification, décalé de offset pas de temps.
+func totpCode(t *testing.T, client models.Client, offset int) string {
+	t.Helper()
+	code, err := totp.Code(client.TOTPSecret, time.Now().Add(time.Duration(offset)*totp.Period*time.Second))
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+	return code
+}
+
+func TestVerifySecondFactorRejectsReplay(t *testing.T) {
+	db := newTestDB(t)
+	client := seedTwoFactorClient(t, db, "staff@test.fr")
+
+	code := totpCode(t, client, 0)
+	if recovery, err := verifySecondFactor(db, client, code, true); err != nil || recovery {
+		t.Fatalf("premier usage du code: (%v, %v), attendu accepté", recovery, err)
+	}
+	var stored models.Client
+	db.First(&stored, "id = ?", client.ID)
+	if stored.TOTPLastStep == 0 {
+		t.Fatal("totp_last_step non enregistré après un code accepté")
+	}
+
+	// Le même code, puis un code plus ancien encore dans la fenêtre de tolérance, sont refusés
+	if _, err := verifySecondFactor(db, client, code, true); !errors.Is(err, errCodeInvalide) {
+		t.Errorf("code rejoué: %v, attendu %v", err, errCodeInvalide)
+	}
+	if _, err := verifySecondFactor(db, client, totpCode(t, client, -1), true); !errors.Is(err, errCodeInvalide) {
+		t.Errorf("code du pas précédent après un code plus récent: %v, attendu %v", err, errCodeInvalide)
+	}
+	// Un code plus récent (horloge du téléphone en avance) reste accepté une fois
+	next := totpCode(t, client, 1)
+	if _, err := verifySecondFactor(db, client, next, false); err != nil {
+		t.Errorf("code du pas suivant: %v, attendu accepté", err)
+	}
+	if _, err := verifySecondFactor(db, client, next, false); !errors.Is(err, errCodeInvalide) {
+		t.Errorf("code du pas suivant rejoué: %v, attendu %v", err, errCodeInvalide)
+	}
+}
+
+func TestVerifySecondFactorRecoveryCodes(t *testing.T) {
+	db := newTestDB(t)
+	client := seedTwoFactorClient(t, db, "staff@test.fr")
+	other := seedTwoFactorClient(t, db, "autre@test.fr")
+	codes, err := generateRecoveryCodes(db, client.ID)
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("%d codes de secours, attendu %d", len(codes), recoveryCodeCount)
+	}
+
+	// Saisi en majuscules et sans tiret, le code reste reconnu
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if recovery, err := verifySecondFactor(db, client, typed, true); err != nil || !recovery {
+		t.Fatalf("code de secours: (%v, %v), attendu accepté comme code de secours", recovery, err)
+	}
+	if _, err := verifySecondFactor(db, client, codes[0], true); !errors.Is(err, errCodeInvalide) {
+		t.Errorf("code de secours réutilisé: %v, attendu %v", err, errCodeInvalide)
+	}
+	if _, err := verifySecondFactor(db, client, codes[1], false); !errors.Is(err, errCodeInvalide) {
+		t.Errorf("code de secours là où il n'est pas permis: %v, attendu %v", err, errCodeInvalide)
+	}
+	if _, err := verifySecondFactor(db, other, codes[1], true); !errors.Is(err, errCodeInvalide) {
+		t.Errorf("code de secours d'un autre compte: %v, attendu %v", err, errCodeInvalide)
+	}
+	// Les codes restants sont toujours valables
+	if _, err := verifySecondFactor(db, client, codes[1], true); err != nil {
+		t.Errorf("second code de secours: %v, attendu accepté", err)
+	}
+
+	// Générer de nouveaux codes invalide les anciens
+	if _, err := generateRecoveryCodes(db, client.ID); err != nil {
+		t.Fatalf("generateRecoveryCodes: %v", err)
+	}
+	if _, err := verifySecondFactor(db, client, codes[2], true); !errors.Is(err, errCodeInvalide) {
+		t.Errorf("ancien code de secours après régénération: %v, attendu %v", err, errCodeInvalide)
+	}
+}
+
+func TestCheckCodeCountsReplayAsFailure(t *testing.T) {
+	db := newTestDB(t)
+	th := NewTwoFactorHandler(db, nil, NewLoginThrottle(db, 5, 20, 15*time.Minute), "Test", false)
+	client := seedTwoFactorClient(t, db, "staff@test.fr")
+	code := totpCode(t, client, 0)
+
+	check := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/2fa/disable", nil)
+		req.RemoteAddr = "10.0.0.1:40000"
+		rec := httptest.NewRecorder()
+		if th.checkCode(rec, req, client, code, false) {
+			return http.StatusOK
+		}
+		return rec.Code
+	}
+	if got := check(); got != http.StatusOK {
+		t.Fatalf("premier usage du code: statut %d, attendu accepté", got)
+	}
+	if got := check(); got != http.StatusBadRequest {
+		t.Fatalf("code rejoué: statut %d, attendu %d", got, http.StatusBadRequest)
+	}
+	var limite models.LimiteConnexion
+	if err := db.Where("kind = ? AND value = ?", models.LimiteParEmail, "staff@test.fr").First(&limite).Error; err != nil || limite.Failures != 1 {
+		t.Errorf("compteur d'échecs après un code rejoué: %+v (%v), attendu 1 échec", limite, err)
+	}
+}
+
+func TestResetHandlerRecordsAudit(t *testing.T) {
+	db := newTestDB(t)
+	th := NewTwoFactorHandler(db, nil, NewLoginThrottle(db, 5, 20, 15*time.Minute), "Test", false)
+	staff := seedTwoFactorClient(t, db, "staff@test.fr")
+	owner := seedClient(t, db, "owner@test.fr")
+	owner.Role = models.RoleOwner
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/staff/"+staff.ID+"/2fa", nil)
+	req = req.WithContext(WithStaff(req.Context(), owner))
+	rec := httptest.NewRecorder()
+	th.ResetHandler(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("statut %d, attendu %d", rec.Code, http.StatusNoContent)
+	}
+
+	var entry models.JournalAudit
+	if err := db.Where("action = ? AND entity_id = ?", AuditTwoFactorReset, staff.ID).First(&entry).Error; err != nil {
+		t.Fatalf("entrée d'audit de la réinitialisation: %v", err)
+	}
+	if entry.ActorID != owner.ID || !strings.Contains(string(entry.Changes), `"totpActive":{"before":true,"after":false}`) {
+		t.Errorf("entrée d'audit %+v (%s): attendu totpActive désactivé par %s", entry, entry.Changes, owner.ID)
+	}
+}
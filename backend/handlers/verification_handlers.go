@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/messaging"
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// MessageVerificationEmail est la nature des emails de confirmation d'adresse dans la file d'envoi.
+const MessageVerificationEmail = "verification_email"
+
+// errVerificationInvalide est renvoyée pour un jeton de confirmation inconnu, utilisé ou expiré.
+var errVerificationInvalide = errors.New("jeton de confirmation invalide ou expiré")
+
+// VerificationHandler gère la confirmation de l'adresse email des clients.
+type VerificationHandler struct {
+	DB     *gorm.DB
+	TTL    time.Duration // Durée de validité d'un lien de confirmation
+	AppURL string        // Adresse utilisée pour le lien envoyé par email
+}
+
+// NewVerificationHandler crée une nouvelle instance de VerificationHandler.
+func NewVerificationHandler(db *gorm.DB, ttl time.Duration, appURL string) *VerificationHandler {
+	return &VerificationHandler{DB: db, TTL: ttl, AppURL: strings.TrimSuffix(appURL, "/")}
+}
+
+// verificationLink renvoie le lien de confirmation envoyé au client.
+func (vh *VerificationHandler) verificationLink(token string) string {
+	return vh.AppURL + "/auth/verify-email?token=" + url.QueryEscape(token)
+}
+
+// invalidateVerificationTokens marque comme utilisés les jetons encore valides d'un client.
+func invalidateVerificationTokens(tx *gorm.DB, clientID string) error {
+	return tx.Model(&models.JetonVerification{}).
+		Where("client_id = ? AND used_at IS NULL", clientID).
+		Update("used_at", time.Now()).Error
+}
+
+// SendVerification envoie au client un nouveau lien de confirmation de son adresse email
+// (les liens précédents ne sont plus valables).
+func (vh *VerificationHandler) SendVerification(client models.Client) error {
+	token, err := newToken("ver_")
+	if err != nil {
+		return err
+	}
+	return vh.DB.Transaction(func(tx *gorm.DB) error {
+		if err := invalidateVerificationTokens(tx, client.ID); err != nil {
+			return err
+		}
+		jeton := models.JetonVerification{
+			ClientID:  client.ID,
+			Email:     client.Email,
+			TokenHash: hashToken(token),
+			ExpiresAt: time.Now().Add(vh.TTL),
+		}
+		if err := tx.Create(&jeton).Error; err != nil {
+			return err
+		}
+		lang := i18n.Resolve(client.Langue)
+		return enqueueTemplate(tx, messaging.ChannelEmail, client.Email, i18n.EmailVerification,
+			lang, MessageVerificationEmail, jeton.ID, map[string]interface{}{
+				"Nom":        client.PrenomClient,
+				"Lien":       vh.verificationLink(token),
+				"Code":       token,
+				"Expiration": i18n.FormatDate(jeton.ExpiresAt, lang),
+			})
+	})
+}
+
+// EmailVerified indique si le client a confirmé son adresse email.
+func EmailVerified(db *gorm.DB, clientID string) (bool, error) {
+	var client models.Client
+	if err := db.Select("email_verifie").First(&client, "id = ?", clientID).Error; err != nil {
+		return false, err
+	}
+	return client.EmailVerifie, nil
+}
+
+// requireVerifiedEmail répond EMAIL_NOT_VERIFIED si le client n'a pas confirmé son adresse.
+func requireVerifiedEmail(w http.ResponseWriter, r *http.Request, db *gorm.DB, clientID string) bool {
+	verified, err := EmailVerified(db, clientID)
+	if err != nil {
+		log.Printf("Erreur DB lors de la vérification de l'adresse email (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return false
+	}
+	if !verified {
+		respondWithError(w, r, http.StatusForbidden, ErrEmailNotVerified)
+		return false
+	}
+	return true
+}
+
+// VerifyHandler confirme l'adresse email à l'aide du jeton reçu par email.
+// Méthodes: GET /auth/verify-email?token=... (lien de l'email) ou POST {"token": ...} (code saisi dans l'application)
+func (vh *VerificationHandler) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	var token string
+	switch r.Method {
+	case http.MethodGet:
+		token = r.URL.Query().Get("token")
+	case http.MethodPost:
+		var req struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrInvalidJSON)
+			return
+		}
+		token = req.Token
+	default:
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrValidationFailed, Field("token", FieldRequired))
+		return
+	}
+
+	var jeton models.JetonVerification
+	err := vh.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", hashToken(token)).First(&jeton).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errVerificationInvalide
+			}
+			return err
+		}
+		if jeton.UsedAt != nil || time.Now().After(jeton.ExpiresAt) {
+			return errVerificationInvalide
+		}
+		// Le jeton ne vaut que pour l'adresse à laquelle il a été envoyé
+		result := tx.Model(&models.Client{}).
+			Where("id = ? AND email = ?", jeton.ClientID, jeton.Email).
+			Update("email_verifie", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVerificationInvalide
+		}
+		return invalidateVerificationTokens(tx, jeton.ClientID)
+	})
+	if err != nil {
+		if errors.Is(err, errVerificationInvalide) {
+			respondWithError(w, r, http.StatusBadRequest, ErrVerificationInvalid, Field("token", FieldInvalid))
+			return
+		}
+		log.Printf("Erreur DB lors de la confirmation de l'adresse email: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"email": jeton.Email, "emailVerifie": true})
+	log.Printf("Adresse email confirmée (client: %s)", jeton.ClientID)
+}
+
+// ResendHandler renvoie un lien de confirmation au client authentifié.
+// Méthode: POST /auth/verify-email/resend
+func (vh *VerificationHandler) ResendHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		return
+	}
+	clientID, ok := ClientIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, r, http.StatusUnauthorized, ErrAuthRequired)
+		return
+	}
+
+	var client models.Client
+	if err := vh.DB.First(&client, "id = ?", clientID).Error; err != nil {
+		log.Printf("Erreur DB lors de la récupération du client (ID: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	if client.EmailVerifie {
+		respondWithError(w, r, http.StatusConflict, ErrEmailAlreadyVerified)
+		return
+	}
+	if err := vh.SendVerification(client); err != nil {
+		log.Printf("Erreur lors de l'envoi du lien de confirmation (client: %s): %v", clientID, err)
+		respondWithError(w, r, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, map[string]string{"status": "accepted"})
+}
@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrModeleInconnu est renvoyée pour une clé absente du catalogue.
+var ErrModeleInconnu = errors.New("modèle de message inconnu")
+
+// Seed enregistre en base les textes par défaut qui n'y sont pas encore.
+// Les textes déjà modifiés par l'équipe ne sont jamais écrasés.
+func Seed(db *gorm.DB) error {
+	for key, def := range Definitions {
+		for lang, text := range def.Defaults {
+			modele := models.ModeleMessage{Key: key, Lang: lang, Subject: text.Subject, Body: text.Body}
+			if err := db.Where("key = ? AND lang = ?", key, lang).FirstOrCreate(&modele).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookup renvoie le texte du modèle dans la langue demandée: celui de la base s'il existe,
+// sinon le texte par défaut. À défaut de texte dans cette langue, le français est utilisé.
+func lookup(db *gorm.DB, key, lang string) (Text, error) {
+	def, ok := Definitions[key]
+	if !ok {
+		return Text{}, fmt.Errorf("%w: %s", ErrModeleInconnu, key)
+	}
+	for _, l := range []string{lang, DefaultLang} {
+		var modele models.ModeleMessage
+		err := db.Where("key = ? AND lang = ?", key, l).First(&modele).Error
+		if err == nil {
+			return Text{Subject: modele.Subject, Body: modele.Body}, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return Text{}, err
+		}
+		if text, ok := def.Defaults[l]; ok {
+			return text, nil
+		}
+	}
+	return Text{}, fmt.Errorf("%w: %s (%s)", ErrModeleInconnu, key, lang)
+}
+
+// execute applique les variables à un texte. Une variable inconnue est une erreur.
+func execute(name, text string, vars map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Render produit l'objet et le corps du message dans la langue demandée.
+func Render(db *gorm.DB, key, lang string, vars map[string]interface{}) (subject, body string, err error) {
+	text, err := lookup(db, key, Resolve(lang))
+	if err != nil {
+		return "", "", err
+	}
+	if subject, err = execute(key+".subject", text.Subject, vars); err != nil {
+		return "", "", err
+	}
+	if body, err = execute(key+".body", text.Body, vars); err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+// Validate vérifie qu'un texte modifié est un modèle valide pour la clé,
+// en l'appliquant aux variables d'exemple de la définition.
+func Validate(key string, text Text) error {
+	def, ok := Definitions[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrModeleInconnu, key)
+	}
+	if _, err := execute(key+".subject", text.Subject, def.Variables); err != nil {
+		return err
+	}
+	_, err := execute(key+".body", text.Body, def.Variables)
+	return err
+}
@@ -0,0 +1,211 @@
+package i18n
+
+// Clés des modèles de messages
+const (
+	NotifCommandeStatut       = "notif_commande_statut"
+	NotifReservationConfirmee = "notif_reservation_confirmee"
+	NotifReservationAnnulee   = "notif_reservation_annulee"
+	NotifReservationRappel    = "notif_reservation_rappel"
+	EmailReservationConfirmee = "email_reservation_confirmee"
+	SMSReservationConfirmee   = "sms_reservation_confirmee"
+	EmailReservationRappel    = "email_reservation_rappel"
+	EmailRecuCommande         = "email_recu_commande"
+	EmailInvitationPersonnel  = "email_invitation_personnel"
+	EmailReinitialisationMDP  = "email_reinitialisation_mot_de_passe"
+	EmailVerification         = "email_verification"
+	NotifCompteVerrouille     = "notif_compte_verrouille"
+	EmailCompteVerrouille     = "email_compte_verrouille"
+)
+
+// Text est le contenu d'un modèle dans une langue. Les variables s'écrivent
+// avec la syntaxe text/template de Go, par exemple {{.Nom}} ou {{.Date}}.
+type Text struct {
+	Subject string // Objet (emails seulement)
+	Body    string
+}
+
+// Definition décrit un modèle: ses variables (avec un exemple, pour valider les modifications)
+// et ses textes par défaut dans chaque langue.
+type Definition struct {
+	Description string
+	Variables   map[string]interface{}
+	Defaults    map[string]Text
+}
+
+// ligneExemple sert d'exemple pour la variable Lignes du reçu.
+type ligneExemple = map[string]interface{}
+
+// Definitions est le catalogue des modèles connus.
+var Definitions = map[string]Definition{
+	NotifCommandeStatut: {
+		Description: "Notification: changement de statut d'une commande",
+		Variables:   map[string]interface{}{"Commande": "1a2b3c4d", "Statut": "Confirmée"},
+		Defaults: map[string]Text{
+			FR: {Body: "Votre commande n°{{.Commande}} est maintenant « {{.Statut}} »."},
+			EN: {Body: "Your order #{{.Commande}} is now \"{{.Statut}}\"."},
+		},
+	},
+	NotifReservationConfirmee: {
+		Description: "Notification: réservation confirmée",
+		Variables:   map[string]interface{}{"Nom": "Ana", "Date": "21/10/2026 à 20h00", "Convives": 2},
+		Defaults: map[string]Text{
+			FR: {Body: "Votre réservation du {{.Date}} pour {{.Convives}} personne(s) est confirmée."},
+			EN: {Body: "Your reservation on {{.Date}} for {{.Convives}} guest(s) is confirmed."},
+		},
+	},
+	NotifReservationAnnulee: {
+		Description: "Notification: réservation annulée",
+		Variables:   map[string]interface{}{"Nom": "Ana", "Date": "21/10/2026 à 20h00", "Convives": 2},
+		Defaults: map[string]Text{
+			FR: {Body: "Votre réservation du {{.Date}} a été annulée."},
+			EN: {Body: "Your reservation on {{.Date}} has been cancelled."},
+		},
+	},
+	NotifReservationRappel: {
+		Description: "Notification: rappel avant une réservation",
+		Variables:   map[string]interface{}{"Nom": "Ana", "Date": "21/10/2026 à 20h00", "Convives": 2},
+		Defaults: map[string]Text{
+			FR: {Body: "Rappel: votre réservation pour {{.Convives}} personne(s) est prévue le {{.Date}}."},
+			EN: {Body: "Reminder: your reservation for {{.Convives}} guest(s) is on {{.Date}}."},
+		},
+	},
+	EmailReservationConfirmee: {
+		Description: "Email: réservation confirmée",
+		Variables:   map[string]interface{}{"Nom": "Ana", "Date": "21/10/2026 à 20h00", "Convives": 2},
+		Defaults: map[string]Text{
+			FR: {
+				Subject: "Votre réservation est confirmée",
+				Body:    "Bonjour {{.Nom}},\n\nVotre réservation du {{.Date}} pour {{.Convives}} personne(s) est confirmée.\n\nÀ bientôt!",
+			},
+			EN: {
+				Subject: "Your reservation is confirmed",
+				Body:    "Hello {{.Nom}},\n\nYour reservation on {{.Date}} for {{.Convives}} guest(s) is confirmed.\n\nSee you soon!",
+			},
+		},
+	},
+	SMSReservationConfirmee: {
+		Description: "SMS: réservation confirmée",
+		Variables:   map[string]interface{}{"Nom": "Ana", "Date": "21/10/2026 à 20h00", "Convives": 2},
+		Defaults: map[string]Text{
+			FR: {Body: "Votre réservation du {{.Date}} pour {{.Convives}} personne(s) est confirmée."},
+			EN: {Body: "Your reservation on {{.Date}} for {{.Convives}} guest(s) is confirmed."},
+		},
+	},
+	EmailReservationRappel: {
+		Description: "Email: rappel avant une réservation",
+		Variables:   map[string]interface{}{"Nom": "Ana", "Date": "21/10/2026 à 20h00", "Convives": 2},
+		Defaults: map[string]Text{
+			FR: {
+				Subject: "Rappel de votre réservation",
+				Body:    "Bonjour {{.Nom}},\n\nNous vous rappelons votre réservation du {{.Date}} pour {{.Convives}} personne(s).\n\nÀ bientôt!",
+			},
+			EN: {
+				Subject: "Your upcoming reservation",
+				Body:    "Hello {{.Nom}},\n\nThis is a reminder of your reservation on {{.Date}} for {{.Convives}} guest(s).\n\nSee you soon!",
+			},
+		},
+	},
+	EmailRecuCommande: {
+		Description: "Email: reçu d'un paiement encaissé",
+		Variables: map[string]interface{}{
+			"Nom": "Ana", "Commande": "1a2b3c4d", "Total": "51.00", "MethodePaiement": "carte", "MontantPaye": "51.00",
+			"Lignes": []ligneExemple{{"Quantite": 2, "Plat": "Filet de Saumon Grillé", "Montant": "51.00"}},
+		},
+		Defaults: map[string]Text{
+			FR: {
+				Subject: "Reçu de votre commande n°{{.Commande}}",
+				Body: "Bonjour {{.Nom}},\n\nMerci pour votre commande n°{{.Commande}}.\n\n" +
+					"{{range .Lignes}}{{.Quantite}} x {{.Plat}}\t{{.Montant}} €\n{{end}}" +
+					"\nTotal: {{.Total}} €\nPayé ({{.MethodePaiement}}): {{.MontantPaye}} €\n",
+			},
+			EN: {
+				Subject: "Receipt for your order #{{.Commande}}",
+				Body: "Hello {{.Nom}},\n\nThank you for your order #{{.Commande}}.\n\n" +
+					"{{range .Lignes}}{{.Quantite}} x {{.Plat}}\t€{{.Montant}}\n{{end}}" +
+					"\nTotal: €{{.Total}}\nPaid ({{.MethodePaiement}}): €{{.MontantPaye}}\n",
+			},
+		},
+	},
+	EmailInvitationPersonnel: {
+		Description: "Email: invitation d'un membre du personnel",
+		Variables: map[string]interface{}{
+			"Role": "serveur", "Lien": "https://restaurant.example/invitation?code=inv_abc", "Code": "inv_abc", "Expiration": "21/10/2026 à 20h00",
+		},
+		Defaults: map[string]Text{
+			FR: {
+				Subject: "Invitation à rejoindre l'équipe du restaurant",
+				Body: "Bonjour,\n\nVous êtes invité(e) à rejoindre l'équipe en tant que {{.Role}}.\n\n" +
+					"Choisissez votre mot de passe ici: {{.Lien}}\n(code d'invitation: {{.Code}})\n\n" +
+					"Cette invitation expire le {{.Expiration}}.\n",
+			},
+			EN: {
+				Subject: "Invitation to join the restaurant team",
+				Body: "Hello,\n\nYou have been invited to join the team as {{.Role}}.\n\n" +
+					"Choose your password here: {{.Lien}}\n(invitation code: {{.Code}})\n\n" +
+					"This invitation expires on {{.Expiration}}.\n",
+			},
+		},
+	},
+	EmailReinitialisationMDP: {
+		Description: "Email: réinitialisation du mot de passe",
+		Variables: map[string]interface{}{
+			"Nom": "Ana", "Lien": "https://restaurant.example/reinitialisation?token=rst_abc", "Code": "rst_abc", "Expiration": "21/10/2026 à 20h00",
+		},
+		Defaults: map[string]Text{
+			FR: {
+				Subject: "Réinitialisation de votre mot de passe",
+				Body: "Bonjour {{.Nom}},\n\nPour choisir un nouveau mot de passe, ouvrez ce lien: {{.Lien}}\n(code: {{.Code}})\n\n" +
+					"Il est valable une seule fois, jusqu'au {{.Expiration}}.\n" +
+					"Si vous n'êtes pas à l'origine de cette demande, ignorez cet email: votre mot de passe reste inchangé.\n",
+			},
+			EN: {
+				Subject: "Reset your password",
+				Body: "Hello {{.Nom}},\n\nTo choose a new password, open this link: {{.Lien}}\n(code: {{.Code}})\n\n" +
+					"It can be used once, until {{.Expiration}}.\n" +
+					"If you did not ask for this, ignore this email: your password stays the same.\n",
+			},
+		},
+	},
+	NotifCompteVerrouille: {
+		Description: "Notification: compte verrouillé après trop d'échecs de connexion",
+		Variables:   map[string]interface{}{"Nom": "Ana", "Echecs": 5, "Jusqua": "21/10/2026 à 20h00"},
+		Defaults: map[string]Text{
+			FR: {Body: "Votre compte a été verrouillé jusqu'au {{.Jusqua}} après {{.Echecs}} tentatives de connexion échouées."},
+			EN: {Body: "Your account was locked until {{.Jusqua}} after {{.Echecs}} failed sign-in attempts."},
+		},
+	},
+	EmailCompteVerrouille: {
+		Description: "Email: compte verrouillé après trop d'échecs de connexion",
+		Variables:   map[string]interface{}{"Nom": "Ana", "Echecs": 5, "Jusqua": "21/10/2026 à 20h00"},
+		Defaults: map[string]Text{
+			FR: {
+				Subject: "Votre compte a été temporairement verrouillé",
+				Body: "Bonjour {{.Nom}},\n\nAprès {{.Echecs}} tentatives de connexion échouées, votre compte est verrouillé jusqu'au {{.Jusqua}}.\n\n" +
+					"Si ce n'était pas vous, nous vous conseillons de changer votre mot de passe (\"Mot de passe oublié\").\n",
+			},
+			EN: {
+				Subject: "Your account has been temporarily locked",
+				Body: "Hello {{.Nom}},\n\nAfter {{.Echecs}} failed sign-in attempts, your account is locked until {{.Jusqua}}.\n\n" +
+					"If this was not you, we recommend changing your password (\"Forgot password\").\n",
+			},
+		},
+	},
+	EmailVerification: {
+		Description: "Email: confirmation de l'adresse email à l'inscription",
+		Variables: map[string]interface{}{
+			"Nom": "Ana", "Lien": "https://restaurant.example/auth/verify-email?token=ver_abc", "Code": "ver_abc", "Expiration": "21/10/2026 à 20h00",
+		},
+		Defaults: map[string]Text{
+			FR: {
+				Subject: "Confirmez votre adresse email",
+				Body: "Bonjour {{.Nom}},\n\nMerci pour votre inscription. Confirmez votre adresse email en ouvrant ce lien: {{.Lien}}\n" +
+					"(code: {{.Code}})\n\nCe lien est valable jusqu'au {{.Expiration}}.\n",
+			},
+			EN: {
+				Subject: "Confirm your email address",
+				Body: "Hello {{.Nom}},\n\nThank you for signing up. Confirm your email address by opening this link: {{.Lien}}\n" +
+					"(code: {{.Code}})\n\nThis link is valid until {{.Expiration}}.\n",
+			},
+		},
+	},
+}
@@ -0,0 +1,130 @@
+// Package i18n fournit le catalogue des messages envoyés aux clients (notifications, emails, SMS)
+// en français et en anglais. Les textes par défaut sont enregistrés en base au démarrage
+// et peuvent être modifiés par l'équipe sans recompiler le serveur.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"restaurant-app/backend/models"
+)
+
+// Langues prises en charge
+const (
+	FR = "fr"
+	EN = "en"
+)
+
+// DefaultLang est la langue utilisée quand aucune préférence n'est connue.
+const DefaultLang = FR
+
+// Supported indique si la langue est prise en charge.
+func Supported(lang string) bool {
+	return lang == FR || lang == EN
+}
+
+// Normalize ramène une langue ("en-GB", "FR") à une langue prise en charge, "" sinon.
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if Supported(lang) {
+		return lang
+	}
+	return ""
+}
+
+// FromAcceptLanguage choisit la langue préférée parmi celles prises en charge,
+// d'après l'en-tête Accept-Language (ex: "en-US,en;q=0.9,fr;q=0.8"). Renvoie "" si aucune ne convient.
+func FromAcceptLanguage(header string) string {
+	type choice struct {
+		lang string
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := Normalize(fields[0])
+		if lang == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{lang, q})
+		}
+	}
+	if len(choices) == 0 {
+		return ""
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].lang
+}
+
+// Resolve renvoie la première langue prise en charge parmi les candidates (préférence, en-tête...),
+// ou la langue par défaut.
+func Resolve(candidates ...string) string {
+	for _, c := range candidates {
+		if lang := Normalize(c); lang != "" {
+			return lang
+		}
+	}
+	return DefaultLang
+}
+
+// FormatDate formate une date et une heure dans la langue donnée.
+func FormatDate(t time.Time, lang string) string {
+	if lang == EN {
+		return t.Format("Jan 2, 2006 at 3:04 PM")
+	}
+	return t.Format("02/01/2006 à 15h04")
+}
+
+// statusLabels traduit les statuts (enregistrés en français) pour les messages en anglais.
+var statusLabels = map[string]string{
+	"En attente":     "Pending",
+	"Confirmée":      "Confirmed",
+	"En préparation": "Being prepared",
+	"Prête":          "Ready",
+	"En livraison":   "Out for delivery",
+	"Livrée":         "Delivered",
+	"Annulée":        "Cancelled",
+	"Terminée":       "Completed",
+}
+
+// roleLabels traduit les rôles du personnel pour les messages.
+var roleLabels = map[string]map[string]string{
+	models.RoleOwner:   {FR: "propriétaire", EN: "owner"},
+	models.RoleManager: {FR: "gérant", EN: "manager"},
+	models.RoleWaiter:  {FR: "serveur", EN: "waiter"},
+	models.RoleChef:    {FR: "cuisinier", EN: "chef"},
+	models.RoleCashier: {FR: "caissier", EN: "cashier"},
+}
+
+// RoleLabel renvoie le libellé d'un rôle du personnel dans la langue donnée.
+func RoleLabel(role, lang string) string {
+	if label, ok := roleLabels[role][lang]; ok {
+		return label
+	}
+	return role
+}
+
+// StatusLabel renvoie le libellé d'un statut dans la langue donnée.
+func StatusLabel(status, lang string) string {
+	if lang == EN {
+		if label, ok := statusLabels[status]; ok {
+			return label
+		}
+	}
+	return status
+}
@@ -1,18 +1,28 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"restaurant-app/backend/handlers" // Importez votre package handlers
-	"restaurant-app/backend/models"   // Assurez-vous que ce chemin correspond à votre projet
+	"restaurant-app/backend/i18n"
+	"restaurant-app/backend/messaging"
+	"restaurant-app/backend/models" // Assurez-vous que ce chemin correspond à votre projet
+	"restaurant-app/backend/payments"
 
 	"github.com/joho/godotenv"
 	"golang.org/x/crypto/bcrypt"
@@ -21,10 +31,95 @@ import (
 )
 
 // Déclarations de variables globales
-var DB *gorm.DB       // Connexion à la base de données GORM
-var adminToken string // Variable globale pour stocker le token admin du .env
-var serverPort string // Variable globale pour stocker le port du serveur
-var serverURL string  // Variable globale pour stocker l'URL du serveur (pour les chemins d'images)
+var DB *gorm.DB                       // Connexion à la base de données GORM
+var serverPort string                 // Variable globale pour stocker le port du serveur
+var serverURL string                  // Variable globale pour stocker l'URL du serveur (pour les chemins d'images)
+var paymentWebhookSecret string       // Secret partagé avec la passerelle pour signer les webhooks de paiement
+var reminderLeadTimes []time.Duration // Délais des rappels avant une réservation (ex: 24h et 2h)
+var reminderInterval time.Duration    // Fréquence de vérification des rappels à envoyer
+var appURL string                     // Adresse de l'application, pour les liens envoyés par email
+var invitationTTL time.Duration       // Durée de validité des invitations du personnel
+var passwordResetTTL time.Duration    // Durée de validité des liens de réinitialisation du mot de passe
+var largeReservationGuests int        // Nombre de convives à partir duquel un compte confirmé est exigé
+
+// Diffuseur des événements temps réel (SSE) vers les applications client et admin
+var events = handlers.NewEventBroker()
+
+// Sessions des clients (jetons d'accès et de rafraîchissement), créées dans init()
+var sessions *handlers.SessionHandler
+
+// Confirmation des adresses email des clients, créée dans init()
+var verifications *handlers.VerificationHandler
+
+// Limitation des tentatives de connexion échouées, créée dans init()
+var loginThrottle *handlers.LoginThrottle
+
+// Double authentification (TOTP) des comptes, créée dans init()
+var twoFactor *handlers.TwoFactorHandler
+
+// newMessageSenders crée les moyens d'envoi des emails et SMS selon la configuration:
+// MAIL_SENDER=smtp (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, MAIL_FROM) ou log (par défaut),
+// SMS_SENDER=http (SMS_API_URL, SMS_API_TOKEN, SMS_FROM) ou log (par défaut).
+// En mode log, les messages sont écrits dans MESSAGE_LOG_FILE, ou dans le journal du serveur.
+func newMessageSenders() (map[string]messaging.Sender, error) {
+	logSender, err := messaging.NewLogSender(os.Getenv("MESSAGE_LOG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+	senders := map[string]messaging.Sender{
+		messaging.ChannelEmail: logSender,
+		messaging.ChannelSMS:   logSender,
+	}
+
+	switch os.Getenv("MAIL_SENDER") {
+	case "", "log":
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		senders[messaging.ChannelEmail] = messaging.NewSMTPSender(os.Getenv("SMTP_HOST"), port,
+			os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	default:
+		return nil, fmt.Errorf("MAIL_SENDER inconnu: %s", os.Getenv("MAIL_SENDER"))
+	}
+
+	switch os.Getenv("SMS_SENDER") {
+	case "", "log":
+	case "http":
+		senders[messaging.ChannelSMS] = messaging.NewHTTPSMSSender(os.Getenv("SMS_API_URL"),
+			os.Getenv("SMS_API_TOKEN"), os.Getenv("SMS_FROM"))
+	default:
+		return nil, fmt.Errorf("SMS_SENDER inconnu: %s", os.Getenv("SMS_SENDER"))
+	}
+	return senders, nil
+}
+
+// durationFromEnv lit une durée (ex: "15m", "720h") dans l'environnement, avec une valeur par défaut.
+func durationFromEnv(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s invalide (%s)", name, value)
+	}
+	return d, nil
+}
+
+// intFromEnv lit un entier strictement positif dans l'environnement, avec une valeur par défaut.
+func intFromEnv(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s invalide (%s)", name, value)
+	}
+	return n, nil
+}
 
 // init est une fonctiq on spéciale de Go qui s'exécute au démarrage du programme, AVANT main().
 func init() {
@@ -36,10 +131,9 @@ func init() {
 		log.Printf("DEBUG GO: AVERTISSEMENT: Pas de fichier .env trouvé, en utilisant les variables d'environnement système ou les valeurs par défaut. Erreur: %v", err)
 	}
 
-	// Récupérer le token admin et le port du serveur
-	adminToken = os.Getenv("ADMIN_TOKEN")
-	if adminToken == "" {
-		log.Println("DEBUG GO: ERREUR DE CONFIGURATION: ADMIN_TOKEN non défini dans .env ou environnement. Les routes admin seront non sécurisées ou inaccessibles.")
+	// Récupérer le port du serveur
+	if os.Getenv("ADMIN_TOKEN") != "" {
+		log.Println("DEBUG GO: ADMIN_TOKEN n'est plus utilisé: l'accès admin dépend du rôle du compte connecté (voir OWNER_EMAIL).")
 	}
 	serverPort = os.Getenv("PORT")
 	if serverPort == "" {
@@ -54,6 +148,81 @@ func init() {
 		log.Printf("DEBUG GO: SERVER_URL non défini dans .env ou environnement. Utilisation du défaut: %s", serverURL)
 	}
 
+	// Récupérer l'adresse de l'application (liens des emails), par défaut celle du serveur
+	appURL = os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = serverURL
+	}
+
+	// Récupérer le secret des webhooks de paiement
+	paymentWebhookSecret = os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if paymentWebhookSecret == "" {
+		log.Println("DEBUG GO: PAYMENT_WEBHOOK_SECRET non défini dans .env ou environnement. Les webhooks de paiement seront refusés.")
+	}
+
+	// Récupérer les délais des rappels de réservation (ex: "24h,2h", vide pour désactiver)
+	leadTimes, ok := os.LookupEnv("RESERVATION_REMINDER_LEAD_TIMES")
+	if !ok {
+		leadTimes = "24h,2h" // Valeur par défaut
+	}
+	reminderLeadTimes, err = handlers.ParseReminderLeadTimes(leadTimes)
+	if err != nil {
+		log.Fatalf("DEBUG GO: RESERVATION_REMINDER_LEAD_TIMES invalide (%s): %v", leadTimes, err)
+	}
+	reminderInterval = time.Minute // Valeur par défaut
+	if interval := os.Getenv("RESERVATION_REMINDER_INTERVAL"); interval != "" {
+		reminderInterval, err = time.ParseDuration(interval)
+		if err != nil || reminderInterval <= 0 {
+			log.Fatalf("DEBUG GO: RESERVATION_REMINDER_INTERVAL invalide (%s): %v", interval, err)
+		}
+	}
+
+	// Récupérer la durée de validité des jetons des clients
+	accessTTL, err := durationFromEnv("ACCESS_TOKEN_TTL", time.Hour)
+	if err != nil {
+		log.Fatal("DEBUG GO: ", err)
+	}
+	refreshTTL, err := durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		log.Fatal("DEBUG GO: ", err)
+	}
+	invitationTTL, err = durationFromEnv("INVITATION_TTL", 72*time.Hour)
+	if err != nil {
+		log.Fatal("DEBUG GO: ", err)
+	}
+	passwordResetTTL, err = durationFromEnv("PASSWORD_RESET_TTL", time.Hour)
+	if err != nil {
+		log.Fatal("DEBUG GO: ", err)
+	}
+	verificationTTL, err := durationFromEnv("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	if err != nil {
+		log.Fatal("DEBUG GO: ", err)
+	}
+
+	// Récupérer le nombre de convives à partir duquel une réservation exige un compte à l'email confirmé
+	largeReservationGuests = 6 // Valeur par défaut
+	if guests := os.Getenv("LARGE_RESERVATION_GUESTS"); guests != "" {
+		largeReservationGuests, err = strconv.Atoi(guests)
+		if err != nil || largeReservationGuests <= 0 {
+			log.Fatalf("DEBUG GO: LARGE_RESERVATION_GUESTS invalide (%s)", guests)
+		}
+	}
+
+	// Limitation des connexions échouées: verrouillage d'un email après LOGIN_MAX_FAILURES échecs,
+	// blocage d'une adresse IP après LOGIN_IP_MAX_FAILURES échecs, pendant LOGIN_LOCK_DURATION
+	loginMaxFailures, err := intFromEnv("LOGIN_MAX_FAILURES", 5)
+	if err != nil {
+		log.Fatal("DEBUG GO: ", err)
+	}
+	loginIPMaxFailures, err := intFromEnv("LOGIN_IP_MAX_FAILURES", 20)
+	if err != nil {
+		log.Fatal("DEBUG GO: ", err)
+	}
+	loginLockDuration, err := durationFromEnv("LOGIN_LOCK_DURATION", 15*time.Minute)
+	if err != nil {
+		log.Fatal("DEBUG GO: ", err)
+	}
+
 	// Connexion à la base de données SQLite
 	// Remplacez 'sqlite.Open("restaurant-app.db")' si vous utilisez une autre base de données
 	DB, err = gorm.Open(sqlite.Open("restaurant-app.db"), &gorm.Config{})
@@ -61,31 +230,96 @@ func init() {
 		log.Fatal("DEBUG GO: Échec de la connexion à la base de données :", err)
 	}
 
+	// Les comptes créés avant la confirmation des adresses email sont considérés comme confirmés
+	emailVerificationIsNew := !DB.Migrator().HasColumn(&models.Client{}, "EmailVerifie")
+
 	// Migration automatique des modèles
 	err = DB.AutoMigrate(
 		&models.Client{},
+		&models.SessionClient{},
+		&models.InvitationPersonnel{},
+		&models.JetonReinitialisation{},
+		&models.JetonVerification{},
+		&models.LimiteConnexion{},
+		&models.JournalAudit{},
+		&models.CodeSecours{},
+		&models.DefiConnexion{},
 		&models.Plat{},
 		&models.Panier{},
 		&models.Reservation{},
+		&models.RappelReservation{},
 		&models.Commande{},
 		&models.Paiement{},
+		&models.Remboursement{},
+		&models.EvenementWebhook{},
 		&models.Notification{},
+		&models.MessageSortant{},
+		&models.ModeleMessage{},
 		&models.CommandePlat{},
+		&models.CommandeStatutHistorique{},
 		&models.PanierPlat{},
 	)
 	if err != nil {
 		log.Fatal("DEBUG GO: Erreur lors de la migration de la base de données :", err)
 	}
+	sessions = handlers.NewSessionHandler(DB, accessTTL, refreshTTL)
+	verifications = handlers.NewVerificationHandler(DB, verificationTTL, appURL)
+	loginThrottle = handlers.NewLoginThrottle(DB, loginMaxFailures, loginIPMaxFailures, loginLockDuration)
+	// STAFF_2FA_REQUIRED=true impose la double authentification à tous les rôles du personnel;
+	// TOTP_ISSUER est le nom affiché dans l'application d'authentification
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+	if totpIssuer == "" {
+		totpIssuer = "Restaurant"
+	}
+	twoFactor = handlers.NewTwoFactorHandler(DB, sessions, loginThrottle, totpIssuer, os.Getenv("STAFF_2FA_REQUIRED") == "true")
+
+	if emailVerificationIsNew {
+		if err := DB.Model(&models.Client{}).Where("1 = 1").Update("email_verifie", true).Error; err != nil {
+			log.Fatal("DEBUG GO: Erreur lors de la migration des adresses email confirmées :", err)
+		}
+	}
+
+	// Rôles du personnel: l'ancien indicateur isAdmin était repris tel quel du corps de l'inscription,
+	// il n'est donc pas digne de confiance. Les comptes créés avant les rôles n'ont aucun rôle:
+	// le propriétaire est désigné par OWNER_EMAIL, puis attribue les autres rôles par /admin/staff/{id}.
+	var legacyAdmins []string
+	if err := DB.Model(&models.Client{}).Where("role IS NULL AND is_admin").Pluck("email", &legacyAdmins).Error; err != nil {
+		log.Fatal("DEBUG GO: Erreur lors de la migration des rôles du personnel :", err)
+	}
+	err = DB.Model(&models.Client{}).Where("role IS NULL").
+		Updates(map[string]interface{}{"role": "", "is_admin": false}).Error
+	if err != nil {
+		log.Fatal("DEBUG GO: Erreur lors de la migration des rôles du personnel :", err)
+	}
+	if len(legacyAdmins) > 0 {
+		log.Printf("DEBUG GO: %d compte(s) marqué(s) isAdmin avant les rôles n'ont plus accès à l'administration (%s). "+
+			"Désignez le propriétaire avec OWNER_EMAIL.", len(legacyAdmins), strings.Join(legacyAdmins, ", "))
+	}
+	// OWNER_EMAIL désigne un compte existant à promouvoir propriétaire (premier déploiement, accès perdu)
+	if ownerEmail := os.Getenv("OWNER_EMAIL"); ownerEmail != "" {
+		result := DB.Model(&models.Client{}).Where("email = ?", ownerEmail).
+			Updates(map[string]interface{}{"role": models.RoleOwner, "is_admin": true})
+		if result.Error != nil {
+			log.Fatal("DEBUG GO: Erreur lors de la promotion du propriétaire :", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			log.Printf("DEBUG GO: OWNER_EMAIL: aucun compte avec l'email %s. Inscrivez-vous puis redémarrez le serveur.", ownerEmail)
+		}
+	}
+
+	// Enregistre les textes par défaut des messages (sans écraser ceux modifiés par l'équipe)
+	if err := i18n.Seed(DB); err != nil {
+		log.Fatal("DEBUG GO: Erreur lors de l'initialisation des modèles de messages :", err)
+	}
 	fmt.Println("DEBUG GO: Base de données connectée et migrée avec succès.")
-	fmt.Printf("DEBUG GO: Serveur prêt sur le port %s. ADMIN_TOKEN configuré.\n", serverPort)
+	fmt.Printf("DEBUG GO: Serveur prêt sur le port %s.\n", serverPort)
 }
 
 // Middleware pour autoriser les requêtes CORS et gérer les requêtes OPTIONS (pre-flight)
 func enableCors(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*") // Autorise toutes les origines pour le développement
 	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-	// Ajout de "X-Admin-Token" aux en-têtes autorisés
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Admin-Token")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	// Gérer la requête OPTIONS pour le pre-flight CORS
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusOK) // Répond 200 OK pour la requête OPTIONS
@@ -93,9 +327,12 @@ func enableCors(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Middleware pour l'authentification Admin
-// Ce middleware protège les routes qui ne doivent être accessibles qu'aux administrateurs.
-func adminAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// Middleware pour l'authentification du personnel
+// Ce middleware protège les routes réservées au personnel (/admin/*, /kitchen/*).
+// Le membre du personnel se connecte par /login et envoie son jeton d'accès ("Authorization: Bearer <jeton>");
+// son rôle (owner, manager, waiter, chef, cashier) doit disposer du droit demandé par la route.
+// Les actions sont attribuées au compte connecté.
+func adminAuthMiddleware(permission string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		enableCors(w, r) // S'assure que CORS est géré pour ce middleware aussi
 
@@ -104,33 +341,86 @@ func adminAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Récupère le token de l'en-tête "X-Admin-Token"
-		token := r.Header.Get("X-Admin-Token")
-		if token == "" {
-			// Alternative: Supporte aussi l'en-tête "Authorization: Bearer <token>"
-			authHeader := r.Header.Get("Authorization")
-			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-				token = authHeader[7:]
-			}
+		session, err := sessions.Authenticate(r)
+		if err != nil {
+			handlers.RespondAuthError(w, r, err)
+			return
+		}
+		staff, err := handlers.AuthorizeStaff(DB, session.ClientID, permission)
+		if err == nil {
+			err = twoFactor.RequireEnrolled(staff)
 		}
+		if err != nil {
+			log.Printf("DEBUG GO: Accès admin refusé (compte: %s, droit: %s): %v", session.ClientID, permission, err)
+			handlers.RespondStaffError(w, r, err)
+			return
+		}
+
+		// Passe au handler suivant en indiquant l'auteur des actions
+		next.ServeHTTP(w, r.WithContext(handlers.WithStaff(r.Context(), staff)))
+	}
+}
+
+// canManageAccount vérifie qu'un compte du personnel n'est modifié ou supprimé
+// que par quelqu'un ayant le droit de gérer le personnel.
+func canManageAccount(w http.ResponseWriter, r *http.Request, client models.Client) bool {
+	actor := handlers.ActorFromContext(r.Context())
+	if client.Role != "" && !handlers.HasPermission(actor.Role, handlers.PermStaff) {
+		handlers.RespondStaffError(w, r, &handlers.PermissionError{Role: actor.Role, Permission: handlers.PermStaff})
+		return false
+	}
+	return true
+}
 
-		// Vérifie si le ADMIN_TOKEN est configuré côté backend
-		if adminToken == "" {
-			log.Println("DEBUG GO: Erreur de configuration: ADMIN_TOKEN non défini dans le backend. Impossible de vérifier l'authentification.")
-			http.Error(w, "Erreur de configuration du serveur. Veuillez contacter l'administrateur.", http.StatusInternalServerError)
+// Middleware pour l'authentification Client
+// Ce middleware protège les routes qui agissent pour le compte d'un client (panier, commandes, etc.).
+// Le jeton d'accès renvoyé par /login doit être envoyé dans l'en-tête "Authorization: Bearer <jeton>".
+func clientAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
 			return
 		}
 
-		// Compare le token fourni avec le token attendu
-		if token != adminToken {
-			log.Printf("DEBUG GO: Accès admin refusé. Token fourni: '%s' (attendu: '%s')", token, adminToken)
-			http.Error(w, "Accès administrateur requis. Authentification invalide.", http.StatusForbidden)
+		session, err := sessions.Authenticate(r)
+		if err != nil {
+			handlers.RespondAuthError(w, r, err)
 			return
 		}
+		next.ServeHTTP(w, r.WithContext(handlers.WithClientID(r.Context(), session.ClientID)))
+	}
+}
+
+// optionalClientID renvoie le client authentifié si la requête porte un jeton d'accès.
+// Sans jeton, la requête est anonyme (ok vaut false); un jeton invalide est une erreur.
+func optionalClientID(r *http.Request) (clientID string, ok bool, err error) {
+	if handlers.BearerToken(r) == "" {
+		return "", false, nil
+	}
+	session, err := sessions.Authenticate(r)
+	if err != nil {
+		return "", false, err
+	}
+	return session.ClientID, true, nil
+}
 
-		log.Println("DEBUG GO: Accès admin autorisé.")
-		next.ServeHTTP(w, r) // Passe au handler suivant si l'authentification réussit
+// reservationAccessible indique si la réservation peut être consultée ou annulée par la requête:
+// une réservation liée à un compte n'est accessible qu'à ce client.
+func reservationAccessible(w http.ResponseWriter, r *http.Request, reservation models.Reservation) bool {
+	if reservation.ClientID == "" {
+		return true
+	}
+	clientID, ok, err := optionalClientID(r)
+	if err != nil {
+		handlers.RespondAuthError(w, r, err)
+		return false
 	}
+	if !ok || clientID != reservation.ClientID {
+		// Même réponse qu'une réservation inexistante, pour ne pas révéler son existence
+		handlers.RespondWithError(w, r, http.StatusNotFound, handlers.ErrReservationNotFound)
+		return false
+	}
+	return true
 }
 
 // --- HANDLERS D'AUTHENTIFICATION (Login et Signup) ---
@@ -146,46 +436,86 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 	err := decoder.Decode(&loginReq)
 	if err != nil {
 		log.Printf("DEBUG GO: Erreur de décodage JSON de la requête de connexion: %v", err)
-		http.Error(w, "Requête invalide", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidJSON)
 		return
 	}
 
 	log.Printf("DEBUG GO: Tentative de connexion pour l'email: %s", loginReq.Email)
 
+	// Refuse l'essai pendant l'attente imposée après des échecs (email ou adresse IP),
+	// avant toute vérification du mot de passe
+	wait, err := loginThrottle.Check(r, loginReq.Email)
+	if err != nil {
+		log.Printf("DEBUG GO: Erreur lors de la vérification des tentatives de connexion: %v", err)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
+		return
+	}
+	if wait > 0 {
+		log.Printf("DEBUG GO: Tentative de connexion refusée pour l'email %s (attente: %s)", loginReq.Email, wait)
+		handlers.RespondThrottled(w, r, wait)
+		return
+	}
+
 	var storedClient models.Client
 	if err := DB.Where("email = ?", loginReq.Email).First(&storedClient).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Printf("DEBUG GO: Client non trouvé pour l'email: %s", loginReq.Email)
-			http.Error(w, "Identifiants incorrects", http.StatusUnauthorized)
+			// Compté comme un mauvais mot de passe, pour ne pas révéler les comptes existants
+			if err := loginThrottle.RecordFailure(r, loginReq.Email); err != nil {
+				log.Printf("DEBUG GO: Erreur lors de l'enregistrement de l'échec de connexion: %v", err)
+			}
+			handlers.RespondWithError(w, r, http.StatusUnauthorized, handlers.ErrInvalidCredentials)
 			return
 		}
 		log.Printf("DEBUG GO: Erreur lors de la récupération du client de la DB: %v", err)
-		http.Error(w, "Erreur interne du serveur", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(storedClient.MotDePasseHashed), []byte(loginReq.MotDePasse))
 	if err != nil {
 		log.Printf("DEBUG GO: Mot de passe incorrect pour l'email %s: %v", loginReq.Email, err)
-		http.Error(w, "Identifiants incorrects", http.StatusUnauthorized)
+		if err := loginThrottle.RecordFailure(r, loginReq.Email); err != nil {
+			log.Printf("DEBUG GO: Erreur lors de l'enregistrement de l'échec de connexion: %v", err)
+		}
+		handlers.RespondWithError(w, r, http.StatusUnauthorized, handlers.ErrInvalidCredentials)
 		return
 	}
 
-	// Connexion réussie - Prépare la réponse pour Flutter
-	clientResponse := map[string]interface{}{
-		"ID":           storedClient.ID,
-		"email":        storedClient.Email,
-		"nomClient":    storedClient.NomClient,
-		"prenomClient": storedClient.PrenomClient,
-		"numTel":       storedClient.NumTel,
-		"adresse":      storedClient.Adresse,
-		"isAdmin":      storedClient.IsAdmin, // Ceci envoie bien le statut isAdmin à Flutter
+	// Double authentification activée: la session n'est ouverte qu'après le code (POST /login/2fa)
+	if storedClient.TOTPActive {
+		challenge, err := twoFactor.StartChallenge(storedClient, r)
+		if err != nil {
+			log.Printf("DEBUG GO: Erreur lors de la création du défi de connexion pour l'email %s: %v", loginReq.Email, err)
+			handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(challenge)
+		log.Printf("DEBUG GO: Mot de passe correct pour l'email %s, code de double authentification attendu", loginReq.Email)
+		return
+	}
+
+	if err := loginThrottle.RecordSuccess(loginReq.Email); err != nil {
+		log.Printf("DEBUG GO: Erreur lors de la remise à zéro des échecs de connexion: %v", err)
 	}
 
+	// Ouvre une session: les jetons sont à envoyer sur les routes client
+	tokens, err := sessions.CreateSession(storedClient.ID, r)
+	if err != nil {
+		log.Printf("DEBUG GO: Erreur lors de la création de la session pour l'email %s: %v", loginReq.Email, err)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
+		return
+	}
+
+	// Connexion réussie - Prépare la réponse pour Flutter (isAdmin, rôle et droits inclus)
+	clientResponse := handlers.LoginResponse(storedClient, tokens)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(clientResponse)
-	log.Printf("DEBUG GO: Connexion réussie pour l'email: %s (isAdmin: %v)", loginReq.Email, storedClient.IsAdmin)
+	log.Printf("DEBUG GO: Connexion réussie pour l'email: %s (rôle: '%s')", loginReq.Email, storedClient.Role)
 }
 
 func signupHandler(w http.ResponseWriter, r *http.Request) {
@@ -201,21 +531,21 @@ func signupHandler(w http.ResponseWriter, r *http.Request) {
 	err := decoder.Decode(&clientData)
 	if err != nil {
 		log.Printf("DEBUG GO: Erreur de décodage JSON à l'inscription: %v", err)
-		http.Error(w, "Requête invalide", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidJSON)
 		return
 	}
 
-	log.Printf("DEBUG GO: Données client reçues pour inscription: Email=%s, Nom=%s, IsAdmin=%v", clientData.Email, clientData.NomClient, clientData.IsAdmin)
+	log.Printf("DEBUG GO: Données client reçues pour inscription: Email=%s, Nom=%s", clientData.Email, clientData.NomClient)
 
 	// Vérifie que l'email n'existe pas déjà
 	var existingClient models.Client
 	if err := DB.Where("email = ?", clientData.Email).First(&existingClient).Error; err == nil {
 		log.Printf("DEBUG GO: Tentative d'inscription avec un email déjà utilisé: %s", clientData.Email)
-		http.Error(w, "Email déjà utilisé", http.StatusConflict)
+		handlers.RespondWithError(w, r, http.StatusConflict, handlers.ErrEmailTaken)
 		return
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) { // Gère les autres erreurs DB
 		log.Printf("DEBUG GO: Erreur DB lors de la vérification de l'email: %v", err)
-		http.Error(w, "Erreur serveur lors de la vérification de l'email.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
@@ -223,7 +553,7 @@ func signupHandler(w http.ResponseWriter, r *http.Request) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(clientData.MotDePasse), bcrypt.DefaultCost)
 	if err != nil {
 		log.Printf("DEBUG GO: Erreur lors du hachage du mot de passe: %v", err)
-		http.Error(w, "Erreur interne du serveur lors du hachage", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
@@ -235,19 +565,29 @@ func signupHandler(w http.ResponseWriter, r *http.Request) {
 		MotDePasseHashed: string(hashedPassword), // Stocke le HACHAGE ici
 		NumTel:           clientData.NumTel,
 		Adresse:          clientData.Adresse,
-		IsAdmin:          clientData.IsAdmin, // Utilise la valeur isAdmin reçue du frontend
+		// isAdmin et le rôle envoyés par l'application sont ignorés: l'inscription crée toujours un client.
+		// Les comptes du personnel sont créés par invitation (/admin/invitations).
+		IsAdmin: false,
+		Role:    "",
+		// Langue des messages: choisie à l'inscription, sinon celle du navigateur
+		Langue: i18n.Resolve(clientData.Langue, i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))),
 	}
 
 	// Ajoute le client dans la base
 	if err := DB.Create(&newClient).Error; err != nil {
 		log.Printf("DEBUG GO: Erreur lors de l'inscription du client dans la DB: %v", err)
-		http.Error(w, "Échec de l'inscription", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
+	// Envoie le lien de confirmation de l'adresse email (renvoi possible par /auth/verify-email/resend)
+	if err := verifications.SendVerification(newClient); err != nil {
+		log.Printf("DEBUG GO: Erreur lors de l'envoi du lien de confirmation à %s: %v", newClient.Email, err)
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	fmt.Fprintln(w, "Inscription réussie")
-	log.Printf("DEBUG GO: Client inscrit avec succès: %s (isAdmin: %v)", newClient.Email, newClient.IsAdmin)
+	log.Printf("DEBUG GO: Client inscrit avec succès: %s", newClient.Email)
 }
 
 // --- HANDLERS DE RÉSERVATION (Côté CLIENT) ---
@@ -261,26 +601,41 @@ func createReservationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method != http.MethodPost {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
 	var reservation models.Reservation
 	if err := json.NewDecoder(r.Body).Decode(&reservation); err != nil {
 		log.Printf("DEBUG GO: Erreur de décodage JSON pour création réservation: %v", err)
-		http.Error(w, "Requête invalide: format JSON incorrect.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidJSON)
 		return
 	}
 
 	// Validation des champs obligatoires
-	if reservation.ClientName == "" || reservation.ClientEmail == "" || reservation.ClientPhone == "" || reservation.NumGuests <= 0 || reservation.ReservationDate.IsZero() {
-		http.Error(w, "Tous les champs obligatoires (nom, email, téléphone, nombre de convives, date) doivent être remplis et valides.", http.StatusBadRequest)
+	var details []handlers.FieldError
+	for field, missing := range map[string]bool{
+		"client_name":      reservation.ClientName == "",
+		"client_email":     reservation.ClientEmail == "",
+		"client_phone":     reservation.ClientPhone == "",
+		"reservation_date": reservation.ReservationDate.IsZero(),
+	} {
+		if missing {
+			details = append(details, handlers.Field(field, handlers.FieldRequired))
+		}
+	}
+	if reservation.NumGuests <= 0 {
+		details = append(details, handlers.Field("num_guests", handlers.FieldMustBePositive))
+	}
+	if len(details) > 0 {
+		sort.Slice(details, func(i, j int) bool { return details[i].Field < details[j].Field })
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrValidationFailed, details...)
 		log.Println("DEBUG GO: Champs obligatoires de réservation manquants ou invalides.")
 		return
 	}
 	// Validation simple de l'email
 	if !regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,6}$`).MatchString(reservation.ClientEmail) {
-		http.Error(w, "Email invalide.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrEmailInvalid, handlers.Field("client_email", handlers.FieldInvalid))
 		log.Printf("DEBUG GO: Email invalide fourni: %s", reservation.ClientEmail)
 		return
 	}
@@ -292,7 +647,7 @@ func createReservationHandler(w http.ResponseWriter, r *http.Request) {
 	todayDateOnly := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
 	if reservationDateOnly.Before(todayDateOnly) {
-		http.Error(w, "La date de réservation ne peut pas être dans le passé.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrReservationInPast, handlers.Field("reservation_date", handlers.FieldInPast))
 		log.Printf("DEBUG GO: Réservation refusée, date dans le passé: %s", reservation.ReservationDate.String())
 		return
 	}
@@ -300,7 +655,7 @@ func createReservationHandler(w http.ResponseWriter, r *http.Request) {
 	// Si la date est aujourd'hui, vérifier que l'heure n'est pas dans le passé
 	if reservationDateOnly.Equal(todayDateOnly) {
 		if reservation.ReservationDate.Before(now) { // Compare la date/heure de la réservation avec l'heure actuelle
-			http.Error(w, "L'heure de réservation ne peut pas être dans le passé pour aujourd'hui.", http.StatusBadRequest)
+			handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrReservationInPast, handlers.Field("reservation_date", handlers.FieldInPast))
 			log.Printf("DEBUG GO: Réservation refusée, heure dans le passé pour aujourd'hui: %s", reservation.ReservationDate.String())
 			return
 		}
@@ -308,12 +663,53 @@ func createReservationHandler(w http.ResponseWriter, r *http.Request) {
 
 	reservation.Status = "En attente" // Définir le statut par défaut pour toute nouvelle réservation
 
+	// La réservation n'est liée à un compte que si le client est authentifié (client_id du corps ignoré)
+	clientID, _, err := optionalClientID(r)
+	if err != nil {
+		handlers.RespondAuthError(w, r, err)
+		return
+	}
+	reservation.ClientID = clientID
+
+	// Une réservation pour un grand groupe exige un compte dont l'adresse email est confirmée
+	if reservation.NumGuests >= largeReservationGuests {
+		verified := false
+		if clientID != "" {
+			if verified, err = handlers.EmailVerified(DB, clientID); err != nil {
+				log.Printf("DEBUG GO: Erreur DB lors de la vérification de l'adresse email (client: %s): %v", clientID, err)
+				handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
+				return
+			}
+		}
+		if !verified {
+			handlers.RespondWithErrorParams(w, r, http.StatusForbidden, handlers.ErrReservationNeedsVerified,
+				map[string]string{"min": strconv.Itoa(largeReservationGuests)})
+			return
+		}
+	}
+
+	// Langue des messages de la réservation: choisie sur la réservation, sinon celle du navigateur,
+	// sinon la préférence du client connecté
+	reservation.Langue = i18n.Normalize(reservation.Langue)
+	if reservation.Langue == "" {
+		reservation.Langue = i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+	}
+	if reservation.Langue == "" && reservation.ClientID != "" {
+		var client models.Client
+		if err := DB.Select("langue").First(&client, "id = ?", reservation.ClientID).Error; err == nil {
+			reservation.Langue = client.Langue
+		}
+	}
+	reservation.Langue = i18n.Resolve(reservation.Langue)
+
 	if err := DB.Create(&reservation).Error; err != nil {
 		log.Printf("DEBUG GO: Erreur DB lors de la création de la réservation: %v", err)
-		http.Error(w, "Échec de la création de la réservation. Veuillez réessayer.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
+	events.Publish(handlers.EventReservationCreated, reservation.ClientID, reservation)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -332,14 +728,14 @@ func getReservationByIDHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method != http.MethodGet {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
 	// Extrait l'ID de la réservation de l'URL
 	pathSegments := strings.Split(r.URL.Path, "/")
 	if len(pathSegments) < 4 || pathSegments[3] == "" { // Vérifie qu'il y a un segment pour l'ID
-		http.Error(w, "ID de réservation manquant ou URL invalide.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidURL)
 		return
 	}
 	id := pathSegments[3] // L'ID est le 4ème segment (indice 3)
@@ -347,12 +743,15 @@ func getReservationByIDHandler(w http.ResponseWriter, r *http.Request) {
 	var reservation models.Reservation
 	if err := DB.Where("id = ?", id).First(&reservation).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Réservation non trouvée.", http.StatusNotFound)
+			handlers.RespondWithError(w, r, http.StatusNotFound, handlers.ErrReservationNotFound)
 			log.Printf("DEBUG GO: Réservation non trouvée pour ID: %s", id)
 			return
 		}
 		log.Printf("DEBUG GO: Erreur DB lors de la récupération de la réservation (ID: %s): %v", id, err)
-		http.Error(w, "Erreur serveur lors de la récupération de la réservation.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
+		return
+	}
+	if !reservationAccessible(w, r, reservation) {
 		return
 	}
 
@@ -370,14 +769,14 @@ func cancelReservationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method != http.MethodPut {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
 	// Extrait l'ID de la réservation de l'URL
 	pathSegments := strings.Split(r.URL.Path, "/")
 	if len(pathSegments) < 5 || pathSegments[4] == "" { // L'ID est le 5ème segment (indice 4)
-		http.Error(w, "ID de réservation manquant ou URL invalide.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidURL)
 		return
 	}
 	id := pathSegments[4]
@@ -385,18 +784,22 @@ func cancelReservationHandler(w http.ResponseWriter, r *http.Request) {
 	var reservation models.Reservation
 	if err := DB.Where("id = ?", id).First(&reservation).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Réservation non trouvée.", http.StatusNotFound)
+			handlers.RespondWithError(w, r, http.StatusNotFound, handlers.ErrReservationNotFound)
 			log.Printf("DEBUG GO: Annulation: Réservation non trouvée pour ID: %s", id)
 			return
 		}
 		log.Printf("DEBUG GO: Erreur DB lors de la récupération pour annulation (ID: %s): %v", id, err)
-		http.Error(w, "Erreur serveur lors de l'annulation.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
+		return
+	}
+
+	if !reservationAccessible(w, r, reservation) {
 		return
 	}
 
 	// Empêche d'annuler une réservation déjà annulée ou terminée
 	if reservation.Status == "Annulée" || reservation.Status == "Terminée" {
-		http.Error(w, "Cette réservation ne peut pas être annulée dans son état actuel.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrReservationNotCancellable)
 		log.Printf("DEBUG GO: Tentative d'annuler une réservation de statut '%s' (ID: %s)", reservation.Status, id)
 		return
 	}
@@ -404,9 +807,14 @@ func cancelReservationHandler(w http.ResponseWriter, r *http.Request) {
 	reservation.Status = "Annulée" // Met à jour le statut
 	if err := DB.Save(&reservation).Error; err != nil {
 		log.Printf("DEBUG GO: Échec de l'enregistrement de l'annulation (ID: %s): %v", id, err)
-		http.Error(w, "Échec de l'annulation de la réservation.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
+	events.Publish(handlers.EventReservationStatusChanged, reservation.ClientID, reservation)
+	if err := handlers.NotifyReservationStatus(DB, reservation); err != nil {
+		log.Printf("DEBUG GO: Échec de la création de la notification d'annulation (ID: %s): %v", id, err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Réservation annulée avec succès!"})
 	log.Printf("DEBUG GO: Réservation annulée par client (ID: %s)", id)
@@ -419,7 +827,7 @@ func cancelReservationHandler(w http.ResponseWriter, r *http.Request) {
 func getAllReservationsAdminHandler(w http.ResponseWriter, r *http.Request) {
 	// Le middleware adminAuthMiddleware a déjà appelé enableCors
 	if r.Method != http.MethodGet {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
@@ -433,7 +841,7 @@ func getAllReservationsAdminHandler(w http.ResponseWriter, r *http.Request) {
 
 	if err := query.Find(&reservations).Error; err != nil {
 		log.Printf("DEBUG GO: Erreur DB lors de la récupération des réservations (filtre: %s): %v", statusFilter, err)
-		http.Error(w, "Échec de la récupération des réservations.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
@@ -447,14 +855,14 @@ func getAllReservationsAdminHandler(w http.ResponseWriter, r *http.Request) {
 func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 	// Le middleware adminAuthMiddleware a déjà appelé enableCors
 	if r.Method != http.MethodPut {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
 	// Extrait l'ID de la réservation de l'URL
 	pathSegments := strings.Split(r.URL.Path, "/")
 	if len(pathSegments) < 4 || pathSegments[3] == "" {
-		http.Error(w, "ID de réservation manquant dans l'URL.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidURL)
 		return
 	}
 	id := pathSegments[3]
@@ -462,12 +870,12 @@ func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 	var reservation models.Reservation
 	if err := DB.Where("id = ?", id).First(&reservation).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Réservation non trouvée.", http.StatusNotFound)
+			handlers.RespondWithError(w, r, http.StatusNotFound, handlers.ErrReservationNotFound)
 			log.Printf("DEBUG GO: Réservation non trouvée pour mise à jour par admin (ID: %s)", id)
 			return
 		}
 		log.Printf("DEBUG GO: Erreur DB lors de la récupération de la réservation pour mise à jour (ID: %s): %v", id, err)
-		http.Error(w, "Erreur serveur.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
@@ -475,15 +883,18 @@ func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 	var updateData map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
 		log.Printf("DEBUG GO: Erreur de décodage JSON pour mise à jour réservation: %v", err)
-		http.Error(w, "Requête invalide: format JSON incorrect.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidJSON)
 		return
 	}
 
+	previousStatus := reservation.Status
+	before := reservation
+
 	// Met à jour les champs (validation manuelle)
 	if status, ok := updateData["status"].(string); ok {
 		validStatuses := map[string]bool{"En attente": true, "Confirmée": true, "Annulée": true, "Terminée": true}
 		if !validStatuses[status] {
-			http.Error(w, "Statut de réservation invalide.", http.StatusBadRequest)
+			handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrReservationStatusInvalid)
 			log.Printf("DEBUG GO: Tentative de mise à jour avec un statut invalide: %s", status)
 			return
 		}
@@ -495,7 +906,7 @@ func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 	if clientEmail, ok := updateData["client_email"].(string); ok {
 		// Re-valider l'email si modifié
 		if !regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,6}$`).MatchString(clientEmail) {
-			http.Error(w, "Email invalide.", http.StatusBadRequest)
+			handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrEmailInvalid, handlers.Field("client_email", handlers.FieldInvalid))
 			log.Printf("DEBUG GO: Email invalide fourni pour update: %s", clientEmail)
 			return
 		}
@@ -506,7 +917,7 @@ func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	if numGuests, ok := updateData["num_guests"].(float64); ok { // JSON décode les nombres en float64
 		if int(numGuests) <= 0 {
-			http.Error(w, "Le nombre de convives doit être supérieur à zéro.", http.StatusBadRequest)
+			handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrValidationFailed, handlers.Field("num_guests", handlers.FieldMustBePositive))
 			return
 		}
 		reservation.NumGuests = int(numGuests)
@@ -516,7 +927,7 @@ func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 		// Doit parser le format ISO 8601 complet (avec heure et Z)
 		parsedDate, err := time.Parse(time.RFC3339Nano, dateStr) // Utilisez time.RFC3339 pour "2025-06-17T18:00:00.000Z"
 		if err != nil {
-			http.Error(w, "Format de date invalide. Attendu ISO 8601 (ex: 2006-01-02T15:04:05Z).", http.StatusBadRequest)
+			handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrDateFormatInvalid, handlers.Field("reservation_date", handlers.FieldInvalid))
 			log.Printf("DEBUG GO: Format de date invalide pour update: %s. Erreur: %v", dateStr, err)
 			return
 		}
@@ -524,7 +935,7 @@ func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 		now := time.Now()
 		// Pour la comparaison "past", utiliser la date complète incluant l'heure
 		if parsedDate.Before(now) {
-			http.Error(w, "La date et l'heure de réservation ne peuvent pas être dans le passé.", http.StatusBadRequest)
+			handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrReservationInPast, handlers.Field("reservation_date", handlers.FieldInPast))
 			log.Printf("DEBUG GO: Mise à jour refusée, date/heure dans le passé: %s", parsedDate.String())
 			return
 		}
@@ -543,11 +954,24 @@ func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 		reservation.WantsReminder = wantsReminder
 	}
 
-	if err := DB.Save(&reservation).Error; err != nil {
+	// La modification et son entrée dans le journal d'audit sont enregistrées ensemble
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&reservation).Error; err != nil {
+			return err
+		}
+		return handlers.RecordAudit(tx, r, handlers.AuditUpdate, handlers.AuditReservation, id, before, reservation)
+	})
+	if err != nil {
 		log.Printf("DEBUG GO: Erreur DB lors de la mise à jour de la réservation (ID: %s): %v", id, err)
-		http.Error(w, "Échec de la mise à jour de la réservation.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
+	if reservation.Status != previousStatus {
+		events.Publish(handlers.EventReservationStatusChanged, reservation.ClientID, reservation)
+		if err := handlers.NotifyReservationStatus(DB, reservation); err != nil {
+			log.Printf("DEBUG GO: Échec de la création de la notification de réservation (ID: %s): %v", id, err)
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -562,27 +986,41 @@ func updateReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 func deleteReservationAdminHandler(w http.ResponseWriter, r *http.Request) {
 	// Le middleware adminAuthMiddleware a déjà appelé enableCors
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
 	// Extrait l'ID de la réservation de l'URL
 	pathSegments := strings.Split(r.URL.Path, "/")
 	if len(pathSegments) < 4 || pathSegments[3] == "" {
-		http.Error(w, "ID de réservation manquant dans l'URL.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidURL)
 		return
 	}
 	id := pathSegments[3]
 
-	// Supprime la réservation par son ID
-	if err := DB.Where("id = ?", id).Delete(&models.Reservation{}).Error; err != nil {
+	// Charge la réservation pour garder son contenu dans le journal d'audit
+	var reservation models.Reservation
+	if err := DB.Where("id = ?", id).First(&reservation).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Réservation non trouvée.", http.StatusNotFound)
+			handlers.RespondWithError(w, r, http.StatusNotFound, handlers.ErrReservationNotFound)
 			log.Printf("DEBUG GO: Réservation non trouvée pour suppression par admin (ID: %s)", id)
 			return
 		}
+		log.Printf("DEBUG GO: Erreur DB lors de la récupération de la réservation pour suppression (ID: %s): %v", id, err)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
+		return
+	}
+
+	// Supprime la réservation par son ID, avec son entrée dans le journal d'audit
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&reservation).Error; err != nil {
+			return err
+		}
+		return handlers.RecordAudit(tx, r, handlers.AuditDelete, handlers.AuditReservation, id, reservation, nil)
+	})
+	if err != nil {
 		log.Printf("DEBUG GO: Erreur DB lors de la suppression de la réservation (ID: %s): %v", id, err)
-		http.Error(w, "Échec de la suppression de la réservation.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
@@ -609,16 +1047,21 @@ func adminClientsHandler(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		createClientAdminHandler(w, r)
 	default:
-		http.Error(w, "Méthode non autorisée pour cette URL.", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 	}
 }
 
 func getAllClientsAdminHandler(w http.ResponseWriter, r *http.Request) {
 	// No need to check method here, it's handled by adminClientsHandler
+	// Les clients effacés (anonymisés) ne sont listés qu'avec ?include_erased=true
+	query := DB
+	if r.URL.Query().Get("include_erased") != "true" {
+		query = query.Where("anonymise_le IS NULL")
+	}
 	var clients []models.Client
-	if err := DB.Find(&clients).Error; err != nil {
+	if err := query.Find(&clients).Error; err != nil {
 		log.Printf("DEBUG GO: Erreur DB lors de la récupération de tous les clients: %v", err)
-		http.Error(w, "Échec de la récupération des clients.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
@@ -634,54 +1077,87 @@ func getAllClientsAdminHandler(w http.ResponseWriter, r *http.Request) {
 
 func createClientAdminHandler(w http.ResponseWriter, r *http.Request) {
 	// No need to check method here, it's handled by adminClientsHandler
-	var newClient models.Client
-	if err := json.NewDecoder(r.Body).Decode(&newClient); err != nil {
+	var clientData models.Client
+	if err := json.NewDecoder(r.Body).Decode(&clientData); err != nil {
 		log.Printf("DEBUG GO: Erreur de décodage JSON pour création client: %v", err)
-		http.Error(w, "Requête invalide: format JSON incorrect.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidJSON)
 		return
 	}
 
 	// Validation des champs obligatoires
-	if newClient.Email == "" || newClient.NomClient == "" || newClient.PrenomClient == "" || newClient.MotDePasse == "" {
-		http.Error(w, "Nom, Prénom, Email et Mot de passe sont requis.", http.StatusBadRequest)
+	var details []handlers.FieldError
+	for field, missing := range map[string]bool{
+		"email":        clientData.Email == "",
+		"nomClient":    clientData.NomClient == "",
+		"prenomClient": clientData.PrenomClient == "",
+		"motDePasse":   clientData.MotDePasse == "",
+	} {
+		if missing {
+			details = append(details, handlers.Field(field, handlers.FieldRequired))
+		}
+	}
+	if len(details) > 0 {
+		sort.Slice(details, func(i, j int) bool { return details[i].Field < details[j].Field })
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrValidationFailed, details...)
 		log.Println("DEBUG GO: Champs obligatoires du client manquants.")
 		return
 	}
-	if !regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,6}$`).MatchString(newClient.Email) {
-		http.Error(w, "Email invalide.", http.StatusBadRequest)
-		log.Printf("DEBUG GO: Email invalide fourni pour création client: %s", newClient.Email)
+	if !regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,6}$`).MatchString(clientData.Email) {
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrEmailInvalid, handlers.Field("email", handlers.FieldInvalid))
+		log.Printf("DEBUG GO: Email invalide fourni pour création client: %s", clientData.Email)
 		return
 	}
 
 	// Vérifier si l'email existe déjà
 	var existingClient models.Client
-	if err := DB.Where("email = ?", newClient.Email).First(&existingClient).Error; err == nil {
-		http.Error(w, "Un client avec cet email existe déjà.", http.StatusConflict)
-		log.Printf("DEBUG GO: Tentative de création client avec email existant: %s", newClient.Email)
+	if err := DB.Where("email = ?", clientData.Email).First(&existingClient).Error; err == nil {
+		handlers.RespondWithError(w, r, http.StatusConflict, handlers.ErrEmailTaken)
+		log.Printf("DEBUG GO: Tentative de création client avec email existant: %s", clientData.Email)
 		return
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		log.Printf("DEBUG GO: Erreur DB lors de la vérification de l'email client: %v", err)
-		http.Error(w, "Erreur serveur lors de la vérification de l'email.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
 	// Hacher le mot de passe
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newClient.MotDePasse), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(clientData.MotDePasse), bcrypt.DefaultCost)
 	if err != nil {
 		log.Printf("DEBUG GO: Erreur lors du hachage du mot de passe pour le nouveau client: %v", err)
-		http.Error(w, "Erreur interne du serveur lors du hachage du mot de passe.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
-	newClient.MotDePasseHashed = string(hashedPassword)
-	newClient.MotDePasse = "" // Effacer le mot de passe en clair
+	// Seuls les champs du profil sont repris de la requête, comme à l'inscription: l'ID, le rôle
+	// (attribué par /admin/staff/{id}), l'email confirmé (par le lien envoyé ci-dessous),
+	// la double authentification (activée par le client) et l'effacement ne se fixent pas ici.
+	newClient := models.Client{
+		Email:            clientData.Email,
+		NomClient:        clientData.NomClient,
+		PrenomClient:     clientData.PrenomClient,
+		MotDePasseHashed: string(hashedPassword),
+		NumTel:           clientData.NumTel,
+		Adresse:          clientData.Adresse,
+		Langue:           clientData.Langue,
+	}
 
-	// Laisser GORM gérer CreatedAt/UpdatedAt
-	if err := DB.Create(&newClient).Error; err != nil {
+	// Laisser GORM gérer CreatedAt/UpdatedAt; le compte et son entrée dans le journal d'audit sont créés ensemble
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newClient).Error; err != nil {
+			return err
+		}
+		return handlers.RecordAudit(tx, r, handlers.AuditCreate, handlers.AuditClient, newClient.ID, nil, handlers.ClientAuditView(newClient))
+	})
+	if err != nil {
 		log.Printf("DEBUG GO: Erreur DB lors de la création du client: %v", err)
-		http.Error(w, "Échec de la création du client.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
+	// Envoie le lien de confirmation de l'adresse email au nouveau client
+	if err := verifications.SendVerification(newClient); err != nil {
+		log.Printf("DEBUG GO: Erreur lors de l'envoi du lien de confirmation à %s: %v", newClient.Email, err)
+	}
+
 	// Ne pas renvoyer le mot de passe haché
 	newClient.MotDePasseHashed = ""
 
@@ -695,13 +1171,13 @@ func createClientAdminHandler(w http.ResponseWriter, r *http.Request) {
 // Méthode: GET /admin/clients/{id}
 func getClientByIDAdminHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
 	pathSegments := strings.Split(r.URL.Path, "/")
 	if len(pathSegments) < 4 || pathSegments[3] == "" {
-		http.Error(w, "ID client manquant dans l'URL.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidURL)
 		return
 	}
 	// L'ID du client est un uint dans le modèle, mais ici dans l'URL, c'est une string
@@ -713,12 +1189,12 @@ func getClientByIDAdminHandler(w http.ResponseWriter, r *http.Request) {
 	// Utilisez First(&client) directement sur la string, GORM devrait gérer la conversion si l'ID dans la DB est un UUID ou si le type GORM uint peut être comparé à une string d'ID
 	if err := DB.Where("ID = ?", clientIDStr).First(&client).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Client non trouvé.", http.StatusNotFound)
+			handlers.RespondWithError(w, r, http.StatusNotFound, handlers.ErrClientNotFound)
 			log.Printf("DEBUG GO: Client non trouvé pour ID: %s", clientIDStr)
 			return
 		}
 		log.Printf("DEBUG GO: Erreur DB lors de la récupération du client (ID: %s): %v", clientIDStr, err)
-		http.Error(w, "Erreur serveur lors de la récupération du client.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
@@ -733,13 +1209,13 @@ func getClientByIDAdminHandler(w http.ResponseWriter, r *http.Request) {
 // Méthode: PUT /admin/clients/{id}
 func updateClientAdminHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
 	pathSegments := strings.Split(r.URL.Path, "/")
 	if len(pathSegments) < 4 || pathSegments[3] == "" {
-		http.Error(w, "ID client manquant dans l'URL.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidURL)
 		return
 	}
 	clientIDStr := pathSegments[3]
@@ -747,21 +1223,29 @@ func updateClientAdminHandler(w http.ResponseWriter, r *http.Request) {
 	var clientToUpdate models.Client
 	if err := DB.Where("ID = ?", clientIDStr).First(&clientToUpdate).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Client non trouvé.", http.StatusNotFound)
+			handlers.RespondWithError(w, r, http.StatusNotFound, handlers.ErrClientNotFound)
 			log.Printf("DEBUG GO: Client non trouvé pour mise à jour par admin (ID: %s)", clientIDStr)
 			return
 		}
 		log.Printf("DEBUG GO: Erreur DB lors de la récupération du client pour mise à jour (ID: %s): %v", clientIDStr, err)
-		http.Error(w, "Erreur serveur.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
+		return
+	}
+	if !canManageAccount(w, r, clientToUpdate) {
+		return
+	}
+	if clientToUpdate.AnonymiseLe != nil {
+		handlers.RespondWithError(w, r, http.StatusConflict, handlers.ErrClientErased)
 		return
 	}
 
 	var updateData map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
 		log.Printf("DEBUG GO: Erreur de décodage JSON pour mise à jour client: %v", err)
-		http.Error(w, "Requête invalide: format JSON incorrect.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidJSON)
 		return
 	}
+	before := handlers.ClientAuditView(clientToUpdate)
 
 	// Appliquer les mises à jour
 	if nomClient, ok := updateData["nomClient"].(string); ok {
@@ -780,8 +1264,13 @@ func updateClientAdminHandler(w http.ResponseWriter, r *http.Request) {
 	if adresse, ok := updateData["adresse"].(string); ok {
 		clientToUpdate.Adresse = adresse
 	}
-	if isAdmin, ok := updateData["isAdmin"].(bool); ok {
-		clientToUpdate.IsAdmin = isAdmin
+	// isAdmin n'est plus modifiable ici: le rôle du personnel s'attribue par /admin/staff/{id}
+	if langue, ok := updateData["langue"].(string); ok {
+		if !i18n.Supported(langue) {
+			handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrLanguageUnsupported, handlers.Field("langue", handlers.FieldInvalid))
+			return
+		}
+		clientToUpdate.Langue = langue
 	}
 
 	// Gérer la réinitialisation du mot de passe si un nouveau mot de passe est fourni (via un champ spécifique)
@@ -789,15 +1278,32 @@ func updateClientAdminHandler(w http.ResponseWriter, r *http.Request) {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 		if err != nil {
 			log.Printf("DEBUG GO: Erreur lors du hachage du nouveau mot de passe pour client (ID: %s): %v", clientIDStr, err)
-			http.Error(w, "Erreur interne du serveur lors du hachage du mot de passe.", http.StatusInternalServerError)
+			handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 			return
 		}
 		clientToUpdate.MotDePasseHashed = string(hashedPassword)
 	}
 
-	if err := DB.Save(&clientToUpdate).Error; err != nil {
+	// Un nouveau mot de passe est tracé à part, pour retrouver facilement les réinitialisations
+	action := handlers.AuditUpdate
+	if newPassword, ok := updateData["newPassword"].(string); ok && newPassword != "" {
+		action = handlers.AuditPasswordReset
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&clientToUpdate).Error; err != nil {
+			return err
+		}
+		// Ancien mot de passe remplacé: les sessions ouvertes et les liens de réinitialisation ne valent plus
+		if action == handlers.AuditPasswordReset {
+			if err := handlers.RevokeCredentials(tx, clientToUpdate.ID); err != nil {
+				return err
+			}
+		}
+		return handlers.RecordAudit(tx, r, action, handlers.AuditClient, clientIDStr, before, handlers.ClientAuditView(clientToUpdate))
+	})
+	if err != nil {
 		log.Printf("DEBUG GO: Erreur DB lors de la mise à jour du client (ID: %s): %v", clientIDStr, err)
-		http.Error(w, "Échec de la mise à jour du client.", http.StatusInternalServerError)
+		handlers.RespondWithError(w, r, http.StatusInternalServerError, handlers.ErrInternal)
 		return
 	}
 
@@ -809,34 +1315,38 @@ func updateClientAdminHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("DEBUG GO: Client mis à jour par admin (ID: %s)", clientIDStr)
 }
 
-// deleteClientAdminHandler supprime un client (par admin).
+// deleteClientAdminHandler supprime un client (par admin): ses données personnelles sont effacées
+// et ses réservations anonymisées; ses commandes et paiements sont conservés pour la comptabilité.
 // Méthode: DELETE /admin/clients/{id}
 func deleteClientAdminHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Méthode non autorisée", http.StatusMethodNotAllowed)
+		handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		return
 	}
 
 	pathSegments := strings.Split(r.URL.Path, "/")
 	if len(pathSegments) < 4 || pathSegments[3] == "" {
-		http.Error(w, "ID client manquant dans l'URL.", http.StatusBadRequest)
+		handlers.RespondWithError(w, r, http.StatusBadRequest, handlers.ErrInvalidURL)
 		return
 	}
 	clientIDStr := pathSegments[3]
 
-	if err := DB.Where("ID = ?", clientIDStr).Delete(&models.Client{}).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Client non trouvé.", http.StatusNotFound)
-			log.Printf("DEBUG GO: Client non trouvé pour suppression par admin (ID: %s)", clientIDStr)
-			return
-		}
-		log.Printf("DEBUG GO: Erreur DB lors de la suppression du client (ID: %s): %v", clientIDStr, err)
-		http.Error(w, "Échec de la suppression du client.", http.StatusInternalServerError)
+	var clientToDelete models.Client
+	if err := DB.Where("ID = ?", clientIDStr).First(&clientToDelete).Error; err == nil && !canManageAccount(w, r, clientToDelete) {
+		return
+	}
+
+	// L'effacement est tracé dans le journal d'audit par EraseClient, dans la même transaction
+	summary, err := handlers.EraseClient(DB, r, clientIDStr)
+	if err != nil {
+		log.Printf("DEBUG GO: Effacement du client refusé ou en échec (ID: %s): %v", clientIDStr, err)
+		handlers.RespondErasureError(w, r, clientIDStr, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent) // 204 No Content pour une suppression réussie
-	log.Printf("DEBUG GO: Client supprimé par admin (ID: %s).", clientIDStr)
+	log.Printf("DEBUG GO: Client effacé par admin (ID: %s): %d réservation(s) anonymisée(s), %d commande(s) conservée(s).",
+		clientIDStr, summary.ReservationsAnonymized, summary.OrdersKept)
 }
 
 // --- FONCTION UTILITAIRE ---
@@ -856,11 +1366,99 @@ func main() {
 	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
 		os.Mkdir(uploadDir, 0755) // Crée le répertoire avec les permissions rwx-rx-rx
 	}
-	dishHandler := handlers.NewDishHandler(DB, adminToken, uploadDir, serverURL)
+	dishHandler := handlers.NewDishHandler(DB, uploadDir, serverURL)
+	cartHandler := handlers.NewCartHandler(DB)
+	orderHandler := handlers.NewOrderHandler(DB, events)
+	kitchenHandler := handlers.NewKitchenHandler(DB, events)
+	notificationHandler := handlers.NewNotificationHandler(DB)
+	templateHandler := handlers.NewTemplateHandler(DB)
+	staffHandler := handlers.NewStaffHandler(DB)
+	invitationHandler := handlers.NewInvitationHandler(DB, sessions, invitationTTL, appURL)
+	passwordHandler := handlers.NewPasswordHandler(DB, loginThrottle, passwordResetTTL, appURL)
+	profileHandler := handlers.NewProfileHandler(DB, verifications, loginThrottle)
+	privacyHandler := handlers.NewPrivacyHandler(DB)
+	auditHandler := handlers.NewAuditHandler(DB)
+	// Passerelle de paiement factice: à remplacer par un prestataire réel implémentant payments.Gateway
+	paymentHandler := handlers.NewPaymentHandler(DB, events, payments.NewFakeGateway(paymentWebhookSecret))
 
 	// --- Routes d'authentification (existantes) ---
 	http.HandleFunc("/login", loginHandler)
 	http.HandleFunc("/signup", signupHandler)
+	// Rafraîchissement des jetons (POST {"refresh_token": ...}) et déconnexion (POST, jeton d'accès requis)
+	http.HandleFunc("/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		sessions.RefreshHandler(w, r)
+	})
+	http.HandleFunc("/auth/logout", func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		sessions.LogoutHandler(w, r)
+	})
+
+	// Mot de passe oublié: envoi d'un lien par email (POST {"email"}), puis choix du nouveau mot de passe
+	// (POST {"token", "motDePasse"})
+	http.HandleFunc("/auth/forgot-password", func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		passwordHandler.ForgotPasswordHandler(w, r)
+	})
+	http.HandleFunc("/auth/reset-password", func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		passwordHandler.ResetPasswordHandler(w, r)
+	})
+
+	// Confirmation de l'adresse email: lien de l'email (GET ?token=) ou code saisi (POST {"token"})
+	http.HandleFunc("/auth/verify-email", func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		verifications.VerifyHandler(w, r)
+	})
+	// Renvoi du lien de confirmation au client connecté (POST)
+	http.HandleFunc("/auth/verify-email/resend", clientAuthMiddleware(verifications.ResendHandler))
+
+	// Profil du client connecté: lecture (GET), modification (PUT, PATCH) et suppression du compte (DELETE {"motDePasse"})
+	http.HandleFunc("/api/me", clientAuthMiddleware(profileHandler.MeHandler))
+	// Changement du mot de passe (POST {"motDePasseActuel", "nouveauMotDePasse"})
+	http.HandleFunc("/api/me/password", clientAuthMiddleware(profileHandler.PasswordHandler))
+	// Export de toutes les données du client connecté, JSON ou ZIP avec ?format=zip (GET)
+	http.HandleFunc("/api/me/export", clientAuthMiddleware(privacyHandler.MyExportHandler))
+
+	// Double authentification (TOTP): seconde étape de la connexion (POST {"mfa_token", "code"}, public)
+	http.HandleFunc("/login/2fa", func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		twoFactor.LoginHandler(w, r)
+	})
+	// État (GET), configuration du secret (POST setup), activation avec un premier code (POST enable {"code"}),
+	// désactivation (POST disable {"code"}) et nouveaux codes de secours (POST recovery-codes {"code"})
+	http.HandleFunc("/auth/2fa", clientAuthMiddleware(twoFactor.StatusHandler))
+	http.HandleFunc("/auth/2fa/setup", clientAuthMiddleware(twoFactor.SetupHandler))
+	http.HandleFunc("/auth/2fa/enable", clientAuthMiddleware(twoFactor.EnableHandler))
+	http.HandleFunc("/auth/2fa/disable", clientAuthMiddleware(twoFactor.DisableHandler))
+	http.HandleFunc("/auth/2fa/recovery-codes", clientAuthMiddleware(twoFactor.RecoveryCodesHandler))
+
+	// Acceptation d'une invitation du personnel: l'invité choisit son mot de passe (POST, public)
+	http.HandleFunc("/invitations/accept", func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		invitationHandler.AcceptHandler(w, r)
+	})
 
 	// --- Routes de Réservation (Côté CLIENT) ---
 	// Pour créer une réservation (POST)
@@ -873,7 +1471,7 @@ func main() {
 
 	// --- Routes de Réservation (Côté ADMIN - Protégées par adminAuthMiddleware) ---
 	// Pour récupérer toutes les réservations (GET)
-	http.HandleFunc("/admin/reservations", adminAuthMiddleware(getAllReservationsAdminHandler))
+	http.HandleFunc("/admin/reservations", adminAuthMiddleware(handlers.PermReservations, getAllReservationsAdminHandler))
 	// Pour mettre à jour/supprimer une réservation par ID (PUT/DELETE)
 	// Note: La même route HandleFunc peut servir pour PUT et DELETE si le handler gère la méthode HTTP.
 	// Dans notre cas, les handlers updateReservationAdminHandler et deleteReservationAdminHandler
@@ -894,11 +1492,11 @@ func main() {
 		case http.MethodDelete:
 			deleteReservationAdminHandler(w, r)
 		default:
-			http.Error(w, "Méthode non autorisée pour cette URL.", http.StatusMethodNotAllowed)
+			handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		}
 	})
 	// Appliquez le middleware d'authentification à ce handler unifié.
-	http.HandleFunc("/admin/reservations/", adminAuthMiddleware(adminReservationsByIdHandler))
+	http.HandleFunc("/admin/reservations/", adminAuthMiddleware(handlers.PermReservations, adminReservationsByIdHandler))
 
 	// --- Routes de Gestion des Plats (DishHandler) ---
 	// Endpoint public pour récupérer tous les plats
@@ -908,7 +1506,7 @@ func main() {
 		dishHandler.GetDishesHandler(w, r)
 	})
 	// Endpoints admin pour créer, mettre à jour, supprimer des plats
-	http.HandleFunc("/admin/dishes", adminAuthMiddleware(dishHandler.CreateDishHandler)) // POST pour la création
+	http.HandleFunc("/admin/dishes", adminAuthMiddleware(handlers.PermMenu, dishHandler.CreateDishHandler)) // POST pour la création
 	// Handler pour les opérations PUT et DELETE sur un plat spécifique par ID
 	adminDishesByIdHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		enableCors(w, r)
@@ -921,16 +1519,16 @@ func main() {
 		case http.MethodDelete:
 			dishHandler.DeleteDishHandler(w, r)
 		default:
-			http.Error(w, "Méthode non autorisée pour cette URL.", http.StatusMethodNotAllowed)
+			handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		}
 	})
-	http.HandleFunc("/admin/dishes/", adminAuthMiddleware(adminDishesByIdHandler)) // Pour ID (PUT/DELETE)
+	http.HandleFunc("/admin/dishes/", adminAuthMiddleware(handlers.PermMenu, adminDishesByIdHandler)) // Pour ID (PUT/DELETE)
 
 	// --- Routes de Gestion des Clients (Côté ADMIN - Protégées par adminAuthMiddleware) ---
 
 	// Consolide GET et POST pour /admin/clients dans un seul handler
 	// C'est la SEULE ligne qui doit enregistrer la route "/admin/clients" (sans slash final)
-	http.HandleFunc("/admin/clients", adminAuthMiddleware(adminClientsHandler))
+	http.HandleFunc("/admin/clients", adminAuthMiddleware(handlers.PermClients, adminClientsHandler))
 
 	// Pour récupérer un client par ID (GET), mettre à jour (PUT) ou supprimer (DELETE)
 	// On crée un handler unifié pour gérer GET, PUT, DELETE sur /admin/clients/{id}
@@ -942,22 +1540,226 @@ func main() {
 
 		switch r.Method {
 		case http.MethodGet:
+			// GET /admin/clients/{id}/export pour toutes les données du client (?format=zip pour une archive)
+			if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/export") {
+				privacyHandler.AdminExportHandler(w, r)
+				return
+			}
 			getClientByIDAdminHandler(w, r)
 		case http.MethodPut:
 			updateClientAdminHandler(w, r)
 		case http.MethodDelete:
 			deleteClientAdminHandler(w, r)
 		default:
-			http.Error(w, "Méthode non autorisée pour cette URL.", http.StatusMethodNotAllowed)
+			handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
+		}
+	})
+	http.HandleFunc("/admin/clients/", adminAuthMiddleware(handlers.PermClients, adminClientsByIdHandler))
+
+	// --- Routes de Gestion des Commandes (Côté ADMIN - Protégées par adminAuthMiddleware) ---
+	// Pour lister les commandes, avec filtre optionnel ?status= (GET)
+	http.HandleFunc("/admin/orders", adminAuthMiddleware(handlers.PermOrders, orderHandler.AdminListOrdersHandler))
+	// GET /admin/orders/{id} pour le détail, PUT /admin/orders/{id}/status pour changer de statut
+	http.HandleFunc("/admin/orders/", adminAuthMiddleware(handlers.PermOrders, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/status") {
+			orderHandler.AdminUpdateOrderStatusHandler(w, r)
+			return
+		}
+		orderHandler.AdminGetOrderHandler(w, r)
+	}))
+
+	// --- Routes du Panier (Côté CLIENT - Protégées par clientAuthMiddleware) ---
+	// GET pour consulter le panier, DELETE pour le vider
+	http.HandleFunc("/api/cart", clientAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			cartHandler.GetCartHandler(w, r)
+		case http.MethodDelete:
+			cartHandler.ClearCartHandler(w, r)
+		default:
+			handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
+		}
+	}))
+	// POST pour ajouter un plat au panier
+	http.HandleFunc("/api/cart/items", clientAuthMiddleware(cartHandler.AddItemHandler))
+	// PUT pour changer la quantité, DELETE pour retirer un plat: /api/cart/items/{platID}
+	http.HandleFunc("/api/cart/items/", clientAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			cartHandler.UpdateItemHandler(w, r)
+		case http.MethodDelete:
+			cartHandler.RemoveItemHandler(w, r)
+		default:
+			handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
 		}
+	}))
+	// POST pour transformer le panier en commande
+	http.HandleFunc("/api/cart/checkout", clientAuthMiddleware(orderHandler.CheckoutHandler))
+
+	// --- Routes des Commandes (Côté CLIENT - Protégées par clientAuthMiddleware) ---
+	// Pour lister ses commandes (GET)
+	http.HandleFunc("/api/orders", clientAuthMiddleware(orderHandler.ListMyOrdersHandler))
+	// GET /api/orders/{id} pour le suivi, POST /api/orders/{id}/reorder pour recommander,
+	// POST /api/orders/{id}/pay pour payer
+	http.HandleFunc("/api/orders/", clientAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/reorder"):
+			orderHandler.ReorderHandler(w, r)
+		case strings.HasSuffix(r.URL.Path, "/pay"):
+			paymentHandler.PayOrderHandler(w, r)
+		default:
+			orderHandler.GetMyOrderHandler(w, r)
+		}
+	}))
+
+	// --- Routes des Notifications (Côté CLIENT - Protégées par clientAuthMiddleware) ---
+	// Liste paginée avec le nombre de non lues (GET), filtre optionnel ?unread=true
+	http.HandleFunc("/api/notifications", clientAuthMiddleware(notificationHandler.ListHandler))
+	// GET /api/notifications/unread-count pour le badge, PUT /api/notifications/read-all pour tout marquer lu,
+	// PUT /api/notifications/{id}/read pour en marquer une, DELETE /api/notifications/{id} pour la supprimer
+	http.HandleFunc("/api/notifications/", clientAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/notifications/unread-count":
+			notificationHandler.UnreadCountHandler(w, r)
+		case "/api/notifications/read-all":
+			notificationHandler.MarkAllReadHandler(w, r)
+		default:
+			notificationHandler.ItemHandler(w, r)
+		}
+	}))
+
+	// --- Routes de Gestion du Personnel (Côté ADMIN - Protégées par adminAuthMiddleware) ---
+	// Rôles disponibles et leurs droits (GET)
+	http.HandleFunc("/admin/roles", adminAuthMiddleware(handlers.PermStaff, staffHandler.ListRolesHandler))
+	// Liste des comptes du personnel (GET)
+	http.HandleFunc("/admin/staff", adminAuthMiddleware(handlers.PermStaff, staffHandler.ListStaffHandler))
+	// PUT /admin/staff/{id} {"role": ...} pour attribuer un rôle, DELETE pour retirer l'accès,
+	// DELETE /admin/staff/{id}/2fa pour réinitialiser la double authentification (téléphone perdu)
+	http.HandleFunc("/admin/staff/", adminAuthMiddleware(handlers.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/2fa") {
+			twoFactor.ResetHandler(w, r)
+			return
+		}
+		staffHandler.ItemHandler(w, r)
+	}))
+	// Invitations du personnel: GET pour lister, POST {"email", "role"} pour inviter
+	http.HandleFunc("/admin/invitations", adminAuthMiddleware(handlers.PermStaff, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			invitationHandler.ListHandler(w, r)
+		case http.MethodPost:
+			invitationHandler.CreateHandler(w, r)
+		default:
+			handlers.RespondWithError(w, r, http.StatusMethodNotAllowed, handlers.ErrMethodNotAllowed)
+		}
+	}))
+	// DELETE /admin/invitations/{id} pour révoquer une invitation en attente
+	http.HandleFunc("/admin/invitations/", adminAuthMiddleware(handlers.PermStaff, invitationHandler.ItemHandler))
+
+	// --- Routes des Verrouillages de connexion (Côté ADMIN - Protégées par adminAuthMiddleware) ---
+	// Emails et adresses IP bloqués après des échecs de connexion, ?all=true et ?kind=email|ip (GET)
+	http.HandleFunc("/admin/lockouts", adminAuthMiddleware(handlers.PermClients, loginThrottle.ListLockoutsHandler))
+	// DELETE /admin/lockouts/{id} pour lever un verrouillage
+	http.HandleFunc("/admin/lockouts/", adminAuthMiddleware(handlers.PermClients, loginThrottle.ClearLockoutHandler))
+
+	// --- Route du Journal d'audit (Côté ADMIN - Protégée par adminAuthMiddleware) ---
+	// Qui a modifié quoi, quand et d'où; filtres ?actor_id=, ?action=, ?entity_type=, ?entity_id=, ?from=, ?to= (GET)
+	http.HandleFunc("/admin/audit", adminAuthMiddleware(handlers.PermAudit, auditHandler.ListHandler))
+
+	// --- Routes des Modèles de messages (Côté ADMIN - Protégées par adminAuthMiddleware) ---
+	// Liste des textes des notifications, emails et SMS, filtre optionnel ?lang= (GET)
+	http.HandleFunc("/admin/templates", adminAuthMiddleware(handlers.PermTemplates, templateHandler.ListTemplatesHandler))
+	// GET pour lire, PUT pour modifier, DELETE pour revenir au texte par défaut: /admin/templates/{key}/{lang}
+	http.HandleFunc("/admin/templates/", adminAuthMiddleware(handlers.PermTemplates, templateHandler.ItemHandler))
+
+	// --- Routes des Paiements ---
+	// Webhook signé de la passerelle de paiement (POST, appelé par le prestataire)
+	http.HandleFunc("/payments/webhook", paymentHandler.WebhookHandler)
+	// Liste des paiements (GET), filtres optionnels ?status= et ?method=
+	http.HandleFunc("/admin/payments", adminAuthMiddleware(handlers.PermPayments, paymentHandler.AdminListPaymentsHandler))
+	// Encaissement d'un paiement en espèces ou au comptoir (PUT /admin/payments/{id}/collect)
+	// et remboursement total ou partiel (POST /admin/payments/{id}/refund)
+	// Le remboursement demande un droit à part (gérant, propriétaire)
+	http.HandleFunc("/admin/payments/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/refund") {
+			adminAuthMiddleware(handlers.PermRefunds, paymentHandler.AdminRefundPaymentHandler)(w, r)
+			return
+		}
+		adminAuthMiddleware(handlers.PermPayments, paymentHandler.AdminCollectPaymentHandler)(w, r)
+	})
+
+	// --- Routes de l'écran Cuisine (KDS - Protégées par adminAuthMiddleware) ---
+	// File des tickets à préparer, filtre optionnel ?station= (GET)
+	http.HandleFunc("/kitchen/tickets", adminAuthMiddleware(handlers.PermKitchen, kitchenHandler.ListTicketsHandler))
+	// Tickets regroupés par poste / catégorie de plat (GET)
+	http.HandleFunc("/kitchen/stations", adminAuthMiddleware(handlers.PermKitchen, kitchenHandler.ListStationsHandler))
+	// Marquer prêt un ticket entier ou une ligne (POST .../bump)
+	http.HandleFunc("/kitchen/tickets/", adminAuthMiddleware(handlers.PermKitchen, kitchenHandler.BumpHandler))
+
+	// --- Routes des Événements temps réel (Server-Sent Events) ---
+	// Flux des changements de statut des commandes et réservations du client (GET)
+	http.HandleFunc("/api/events", clientAuthMiddleware(events.ClientStreamHandler))
+	// Flux admin: nouvelles commandes, nouvelles réservations et changements de statut (GET)
+	http.HandleFunc("/admin/events", adminAuthMiddleware(handlers.PermEvents, events.AdminStreamHandler))
+
+	// Toute autre URL: erreur JSON ROUTE_NOT_FOUND (au lieu de la page 404 en texte du serveur)
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		enableCors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		handlers.RespondWithError(w, r, http.StatusNotFound, handlers.ErrRouteNotFound)
 	})
-	http.HandleFunc("/admin/clients/", adminAuthMiddleware(adminClientsByIdHandler))
 
 	// Route pour servir les fichiers statiques (images uploadées)
 	// Assurez-vous que votre dossier 'uploads' existe au même niveau que votre exécutable Go
 	http.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
 
+	// Arrêt propre sur Ctrl+C ou SIGTERM: le contexte est annulé pour les tâches de fond
+	// et les flux SSE ouverts, puis le serveur termine les requêtes en cours.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Tâches de fond (rappels de réservation, envoi des messages)
+	var background sync.WaitGroup
+	reminders := handlers.NewReminderScheduler(DB, events, reminderLeadTimes, reminderInterval)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		reminders.Run(ctx)
+	}()
+
+	// File d'envoi des emails et SMS
+	senders, err := newMessageSenders()
+	if err != nil {
+		log.Fatal("DEBUG GO: Configuration des envois de messages invalide :", err)
+	}
+	outbox := messaging.NewOutbox(DB, senders, 10*time.Second)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		outbox.Run(ctx)
+	}()
+
 	// Démarrer le serveur HTTP
-	log.Printf("DEBUG GO: Serveur démarré sur le port %s...", serverPort)
-	log.Fatal(http.ListenAndServe(":"+serverPort, nil))
+	server := &http.Server{
+		Addr:        ":" + serverPort,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		log.Printf("DEBUG GO: Serveur démarré sur le port %s...", serverPort)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("DEBUG GO: Arrêt du serveur demandé...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("DEBUG GO: Erreur lors de l'arrêt du serveur: %v", err)
+	}
+	background.Wait()
+	log.Println("DEBUG GO: Serveur arrêté.")
 }
@@ -0,0 +1,43 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogSender n'envoie rien: il écrit les messages dans un fichier, ou dans le journal du serveur
+// si aucun fichier n'est configuré. Utile en développement pour lire les emails et SMS envoyés.
+type LogSender struct {
+	mu  sync.Mutex
+	out io.Writer // nil: journal du serveur
+}
+
+// NewLogSender crée un expéditeur qui écrit dans le fichier donné (journal du serveur si vide).
+func NewLogSender(path string) (*LogSender, error) {
+	if path == "" {
+		return &LogSender{}, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &LogSender{out: f}, nil
+}
+
+// Send écrit le message.
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	if s.out == nil {
+		log.Printf("Message %s à %s: %s\n%s", msg.Channel, msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.out, "--- %s | %s | à: %s | objet: %s\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.Channel, msg.To, msg.Subject, msg.Body)
+	return err
+}
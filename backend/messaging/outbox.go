@@ -0,0 +1,152 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"restaurant-app/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Réglages par défaut des réessais
+const (
+	defaultMaxAttempts = 6
+	backoffBase        = 30 * time.Second
+	backoffMax         = time.Hour
+	outboxBatchSize    = 20
+	sendTimeout        = 30 * time.Second
+)
+
+// Enqueue ajoute un message à la file d'envoi. Appelée dans la transaction de l'action
+// qui déclenche le message, pour que le message ne parte que si l'action est enregistrée.
+// Un destinataire vide est ignoré (ex: réservation sans téléphone).
+func Enqueue(tx *gorm.DB, msg Message) error {
+	if msg.To == "" {
+		return nil
+	}
+	return tx.Create(&models.MessageSortant{
+		Channel:       msg.Channel,
+		Recipient:     msg.To,
+		Subject:       msg.Subject,
+		Body:          msg.Body,
+		Kind:          msg.Kind,
+		ReferenceID:   msg.ReferenceID,
+		Status:        models.MessageEnAttente,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// Outbox envoie en tâche de fond les messages en attente, avec un Sender par canal.
+// Un envoi en échec est réessayé avec un délai croissant (30s, 1min, 2min... jusqu'à 1h),
+// puis abandonné après MaxAttempts essais.
+type Outbox struct {
+	DB          *gorm.DB
+	Senders     map[string]Sender // Sender par canal (ChannelEmail, ChannelSMS)
+	Interval    time.Duration     // Fréquence de vérification de la file
+	MaxAttempts int
+}
+
+// NewOutbox crée une nouvelle file d'envoi.
+func NewOutbox(db *gorm.DB, senders map[string]Sender, interval time.Duration) *Outbox {
+	return &Outbox{DB: db, Senders: senders, Interval: interval, MaxAttempts: defaultMaxAttempts}
+}
+
+// Run envoie les messages dus à chaque intervalle, jusqu'à l'annulation du contexte.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.Interval)
+	defer ticker.Stop()
+	for {
+		o.flush(ctx)
+		select {
+		case <-ctx.Done():
+			log.Println("File d'envoi des messages arrêtée.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff renvoie le délai avant le prochain essai après n échecs.
+func backoff(attempts int) time.Duration {
+	d := backoffBase
+	for i := 1; i < attempts && d < backoffMax; i++ {
+		d *= 2
+	}
+	if d > backoffMax {
+		d = backoffMax
+	}
+	return d
+}
+
+// flush envoie les messages dont l'heure d'essai est arrivée, jusqu'à vider la file.
+func (o *Outbox) flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		var messages []models.MessageSortant
+		if err := o.DB.WithContext(ctx).
+			Where("status = ? AND next_attempt_at <= ?", models.MessageEnAttente, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(outboxBatchSize).
+			Find(&messages).Error; err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Erreur DB lors de la lecture de la file d'envoi: %v", err)
+			}
+			return
+		}
+		for i := range messages {
+			if ctx.Err() != nil {
+				return
+			}
+			o.deliver(ctx, &messages[i])
+		}
+		if len(messages) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// deliver envoie un message et enregistre le résultat de l'essai.
+func (o *Outbox) deliver(ctx context.Context, m *models.MessageSortant) {
+	var err error
+	sender, ok := o.Senders[m.Channel]
+	if !ok {
+		err = fmt.Errorf("aucun moyen d'envoi configuré pour le canal '%s'", m.Channel)
+	} else {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err = sender.Send(sendCtx, Message{
+			Channel:     m.Channel,
+			To:          m.Recipient,
+			Subject:     m.Subject,
+			Body:        m.Body,
+			Kind:        m.Kind,
+			ReferenceID: m.ReferenceID,
+		})
+		cancel()
+	}
+	if err != nil && ctx.Err() != nil {
+		return // Arrêt du serveur: l'essai n'est pas compté
+	}
+
+	m.Attempts++
+	now := time.Now()
+	if err == nil {
+		m.Status = models.MessageEnvoye
+		m.SentAt = &now
+		m.LastError = ""
+	} else {
+		m.LastError = err.Error()
+		if m.Attempts >= o.MaxAttempts {
+			m.Status = models.MessageEchoue
+			log.Printf("Message abandonné après %d essais (ID: %s, %s à %s): %v", m.Attempts, m.ID, m.Channel, m.Recipient, err)
+		} else {
+			m.NextAttemptAt = now.Add(backoff(m.Attempts))
+			log.Printf("Échec de l'envoi du message (ID: %s, essai %d), nouvel essai à %s: %v",
+				m.ID, m.Attempts, m.NextAttemptAt.Format(time.RFC3339), err)
+		}
+	}
+	if err := o.DB.Save(m).Error; err != nil {
+		log.Printf("Erreur DB lors de la mise à jour du message (ID: %s): %v", m.ID, err)
+	}
+}
@@ -0,0 +1,29 @@
+// Package messaging envoie les messages sortants (emails, SMS) aux clients.
+// Les messages sont d'abord enregistrés dans une file persistante (outbox) puis envoyés
+// en tâche de fond avec réessais, pour qu'un serveur de mail lent ou en panne ne fasse
+// jamais échouer une requête HTTP.
+package messaging
+
+import "context"
+
+// Canaux d'envoi des messages
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Message est un message à envoyer à un destinataire sur un canal.
+type Message struct {
+	Channel     string // ChannelEmail ou ChannelSMS
+	To          string // Adresse email ou numéro de téléphone
+	Subject     string // Objet (ignoré pour les SMS)
+	Body        string
+	Kind        string // Nature du message (ex: "reservation_confirmee"), pour le suivi
+	ReferenceID string // ID de l'objet concerné (réservation, commande...)
+}
+
+// Sender est l'interface que doit implémenter chaque moyen d'envoi (SMTP, SMS, journal...).
+type Sender interface {
+	// Send envoie le message. Une erreur déclenche un nouvel essai plus tard.
+	Send(ctx context.Context, msg Message) error
+}
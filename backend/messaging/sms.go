@@ -0,0 +1,53 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPSMSSender envoie les SMS via l'API HTTP d'un fournisseur: POST JSON
+// {"from": ..., "to": ..., "message": ...} sur l'URL configurée, avec un jeton Bearer optionnel.
+// En développement, l'URL peut pointer vers un simple serveur HTTP local.
+type HTTPSMSSender struct {
+	URL    string
+	Token  string
+	From   string
+	Client *http.Client
+}
+
+// NewHTTPSMSSender crée un nouvel expéditeur de SMS par HTTP.
+func NewHTTPSMSSender(url, token, from string) *HTTPSMSSender {
+	return &HTTPSMSSender{URL: url, Token: token, From: from, Client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Send envoie le SMS. Toute réponse hors 2xx est une erreur.
+func (s *HTTPSMSSender) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{"from": s.From, "to": msg.To, "message": msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("fournisseur SMS: statut %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -18,13 +18,29 @@ type Client struct {
 	MotDePasseHashed string         `gorm:"column:mot_de_passe_hashed" json:"-"` // Champ pour le mot de passe haché stocké en base de données
 	NumTel           string         `json:"numTel"`
 	Adresse          string         `json:"adresse"`
-	IsAdmin          bool           `gorm:"default:false" json:"isAdmin"` // Indique si l'utilisateur est administrateur
+	IsAdmin          bool           `gorm:"default:false" json:"isAdmin"`               // Indique si l'utilisateur fait partie du personnel (Role non vide)
+	Role             string         `gorm:"type:varchar(20);index" json:"role"`         // Rôle du personnel (owner, manager, ...), vide pour un client
+	Langue           string         `gorm:"type:varchar(5);default:'fr'" json:"langue"` // Langue des messages (fr, en)
+	EmailVerifie     bool           `gorm:"default:false" json:"emailVerifie"`          // Adresse confirmée par le lien envoyé à l'inscription
+	TOTPSecret       string         `gorm:"column:totp_secret" json:"-"`                // Secret de la double authentification (en attente tant que TOTPActive est faux)
+	TOTPActive       bool           `gorm:"column:totp_active;default:false" json:"totpActive"`
+	TOTPLastStep     int64          `gorm:"column:totp_last_step;default:0" json:"-"` // Pas de temps du dernier code accepté (un code ne sert qu'une fois)
+	AnonymiseLe      *time.Time     `gorm:"index" json:"anonymiseLe,omitempty"`       // Données personnelles effacées (compte supprimé), commandes conservées
 	Paniers          []Panier       `gorm:"foreignKey:ClientID"`
 	Reservations     []Reservation  `gorm:"foreignKey:ClientID"`
 	Commandes        []Commande     `gorm:"foreignKey:ClientID"`
 	Notifications    []Notification `gorm:"foreignKey:ClientID"`
 }
 
+// Rôles du personnel. Un compte sans rôle est un simple client.
+const (
+	RoleOwner   = "owner"   // Propriétaire: tous les droits, dont la gestion du personnel
+	RoleManager = "manager" // Gérant
+	RoleWaiter  = "waiter"  // Serveur
+	RoleChef    = "chef"    // Cuisinier
+	RoleCashier = "cashier" // Caissier
+)
+
 // UnmarshalJSON pour Client (Gère la désérialisation de 'isAdmin' qui peut être bool ou nombre)
 func (c *Client) UnmarshalJSON(data []byte) error {
 	type Alias Client
@@ -84,6 +100,187 @@ func (p *Plat) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// SessionClient struct (Session d'un client connecté)
+// Les jetons ne sont jamais stockés en clair: seule leur empreinte SHA-256 est enregistrée.
+type SessionClient struct {
+	ID               string     `gorm:"type:uuid;primaryKey" json:"ID"`
+	ClientID         string     `gorm:"type:uuid;not null;index" json:"client_id"`
+	AccessTokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	RefreshTokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	AccessExpiresAt  time.Time  `json:"access_expires_at"`
+	RefreshExpiresAt time.Time  `json:"refresh_expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at"` // Déconnexion, rotation du jeton de rafraîchissement ou révocation
+	UserAgent        string     `json:"user_agent"`
+	IP               string     `json:"ip"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook pour SessionClient (Génère un UUID avant la création)
+func (s *SessionClient) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
+
+// InvitationPersonnel struct (Invitation d'un membre du personnel, à usage unique et à durée limitée)
+// Le code n'est jamais stocké en clair: seule son empreinte SHA-256 est enregistrée.
+type InvitationPersonnel struct {
+	ID         string     `gorm:"type:uuid;primaryKey" json:"ID"`
+	Email      string     `gorm:"not null;index" json:"email"`
+	Role       string     `gorm:"type:varchar(20);not null" json:"role"`
+	Langue     string     `gorm:"type:varchar(5);default:'fr'" json:"langue"`
+	CodeHash   string     `gorm:"uniqueIndex;not null" json:"-"`
+	InvitedBy  string     `gorm:"type:uuid" json:"invited_by"` // Membre du personnel ayant envoyé l'invitation
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	ClientID   string     `gorm:"type:uuid" json:"client_id"` // Compte créé à l'acceptation
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook pour InvitationPersonnel (Génère un UUID avant la création)
+func (i *InvitationPersonnel) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return
+}
+
+// JetonReinitialisation struct (Jeton de réinitialisation du mot de passe, à usage unique et à durée limitée)
+// Le jeton n'est jamais stocké en clair: seule son empreinte SHA-256 est enregistrée.
+type JetonReinitialisation struct {
+	ID        string     `gorm:"type:uuid;primaryKey" json:"ID"`
+	ClientID  string     `gorm:"type:uuid;not null;index" json:"client_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // Utilisé, ou remplacé par une nouvelle demande
+	IP        string     `json:"ip"`      // Adresse de la demande
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook pour JetonReinitialisation (Génère un UUID avant la création)
+func (j *JetonReinitialisation) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	return
+}
+
+// JetonVerification struct (Jeton de confirmation de l'adresse email d'un client)
+// Le jeton n'est jamais stocké en clair: seule son empreinte SHA-256 est enregistrée.
+type JetonVerification struct {
+	ID        string     `gorm:"type:uuid;primaryKey" json:"ID"`
+	ClientID  string     `gorm:"type:uuid;not null;index" json:"client_id"`
+	Email     string     `gorm:"not null" json:"email"` // Adresse à confirmer (le jeton ne vaut que pour elle)
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // Utilisé, ou remplacé par un nouvel envoi
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook pour JetonVerification (Génère un UUID avant la création)
+func (j *JetonVerification) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	return
+}
+
+// CodeSecours struct (Code de secours de la double authentification, à usage unique)
+// Il remplace le code de l'application d'authentification en cas de perte du téléphone.
+// Seule son empreinte SHA-256 est enregistrée.
+type CodeSecours struct {
+	ID        string     `gorm:"type:uuid;primaryKey" json:"ID"`
+	ClientID  string     `gorm:"type:uuid;not null;index" json:"client_id"`
+	CodeHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook pour CodeSecours (Génère un UUID avant la création)
+func (c *CodeSecours) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return
+}
+
+// DefiConnexion struct (Connexion en attente du second facteur)
+// Après un mot de passe correct, le jeton du défi est échangé contre une session avec un code valide.
+type DefiConnexion struct {
+	ID        string     `gorm:"type:uuid;primaryKey" json:"ID"`
+	ClientID  string     `gorm:"type:uuid;not null;index" json:"client_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	Attempts  int        `gorm:"default:0" json:"attempts"` // Codes incorrects déjà saisis
+	UsedAt    *time.Time `json:"used_at"`
+	IP        string     `json:"ip"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook pour DefiConnexion (Génère un UUID avant la création)
+func (d *DefiConnexion) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return
+}
+
+// Types de compteurs d'échecs de connexion
+const (
+	LimiteParEmail = "email"
+	LimiteParIP    = "ip"
+	// Demandes de réinitialisation du mot de passe (comptées comme des échecs)
+	LimiteReinitEmail = "rst_email"
+	LimiteReinitIP    = "rst_ip"
+)
+
+// LimiteConnexion struct (Compteur des échecs de connexion pour un email ou une adresse IP)
+// Il est enregistré en base pour que l'attente progressive et les verrouillages survivent à un redémarrage.
+type LimiteConnexion struct {
+	ID            string     `gorm:"type:uuid;primaryKey" json:"ID"`
+	Kind          string     `gorm:"type:varchar(10);not null;uniqueIndex:idx_limite_connexion" json:"kind"` // "email", "ip", "rst_email" ou "rst_ip"
+	Value         string     `gorm:"not null;uniqueIndex:idx_limite_connexion" json:"value"`                 // Email (en minuscules) ou adresse IP
+	Failures      int        `gorm:"default:0" json:"failures"`                                              // Échecs consécutifs
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	BlockedUntil  time.Time  `json:"blocked_until"` // Aucun essai accepté avant cette date
+	LockedAt      *time.Time `json:"locked_at"`     // Verrouillage après trop d'échecs
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate hook pour LimiteConnexion (Génère un UUID avant la création)
+func (l *LimiteConnexion) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return
+}
+
+// JournalAudit struct (Trace d'une modification faite depuis l'administration: qui, quand, d'où et quoi)
+// Les entrées ne sont jamais supprimées; seules les données personnelles d'un client effacé y sont remplacées.
+type JournalAudit struct {
+	ID         string          `gorm:"type:uuid;primaryKey" json:"ID"`
+	ActorType  string          `gorm:"type:varchar(20)" json:"actor_type"`                         // admin, client ou system (voir handlers.ActorAdmin)
+	ActorID    string          `gorm:"index" json:"actor_id"`                                      // Compte à l'origine de la modification
+	ActorRole  string          `gorm:"type:varchar(20)" json:"actor_role"`                         // Rôle du compte au moment de la modification
+	IP         string          `json:"ip"`                                                         // Adresse IP d'origine de la requête
+	Action     string          `gorm:"type:varchar(30);index" json:"action"`                       // create, update, delete, erase, role_change, ...
+	EntityType string          `gorm:"type:varchar(30);index:idx_audit_entite" json:"entity_type"` // reservation, client, plat, ...
+	EntityID   string          `gorm:"index:idx_audit_entite" json:"entity_id"`
+	Changes    json.RawMessage `gorm:"type:text" json:"changes"` // Champs modifiés: {"champ": {"before": ..., "after": ...}}
+	CreatedAt  time.Time       `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// BeforeCreate hook pour JournalAudit (Génère un UUID avant la création)
+func (j *JournalAudit) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	return
+}
+
 // Reservation struct (Modèle de réservation pour la base de données)
 type Reservation struct {
 	ID               string    `gorm:"type:uuid;primaryKey" json:"ID"` // ID réservation (UUID string)
@@ -98,6 +295,7 @@ type Reservation struct {
 	IsSpecialEvent   bool      `gorm:"default:false" json:"is_special_event"`
 	EventDescription string    `json:"event_description"`
 	WantsReminder    bool      `gorm:"default:true" json:"wants_reminder"`
+	Langue           string    `gorm:"type:varchar(5);default:'fr'" json:"langue"` // Langue des messages (fr, en)
 	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -110,6 +308,23 @@ func (r *Reservation) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// RappelReservation struct (Rappel envoyé pour une réservation, pour ne jamais l'envoyer deux fois)
+// LeadMinutes est le délai avant la réservation auquel correspond le rappel (ex: 1440 pour 24h).
+type RappelReservation struct {
+	ID            string    `gorm:"type:uuid;primaryKey" json:"ID"`
+	ReservationID string    `gorm:"type:uuid;not null;uniqueIndex:idx_rappel_reservation_lead" json:"reservation_id"`
+	LeadMinutes   int       `gorm:"not null;uniqueIndex:idx_rappel_reservation_lead" json:"lead_minutes"`
+	SentAt        time.Time `gorm:"autoCreateTime" json:"sent_at"`
+}
+
+// BeforeCreate hook pour RappelReservation (Génère un UUID avant la création)
+func (rr *RappelReservation) BeforeCreate(tx *gorm.DB) (err error) {
+	if rr.ID == "" {
+		rr.ID = uuid.New().String()
+	}
+	return
+}
+
 // Panier struct (Modèle de panier pour la base de données)
 type Panier struct {
 	ID        string    `gorm:"type:uuid;primaryKey" json:"ID"`                  // ID panier (UUID string)
@@ -126,15 +341,66 @@ func (p *Panier) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// Statuts possibles d'une commande (cycle de vie)
+const (
+	CommandeEnAttente     = "En attente"
+	CommandeConfirmee     = "Confirmée"
+	CommandeEnPreparation = "En préparation"
+	CommandePrete         = "Prête"
+	CommandeEnLivraison   = "En livraison"
+	CommandeLivree        = "Livrée"
+	CommandeAnnulee       = "Annulée"
+)
+
+// transitionsCommande liste, pour chaque statut, les statuts suivants autorisés.
+// Le premier statut de chaque liste est l'étape "normale" suivante.
+var transitionsCommande = map[string][]string{
+	CommandeEnAttente:     {CommandeConfirmee, CommandeAnnulee},
+	CommandeConfirmee:     {CommandeEnPreparation, CommandeAnnulee},
+	CommandeEnPreparation: {CommandePrete, CommandeAnnulee},
+	CommandePrete:         {CommandeEnLivraison, CommandeLivree, CommandeAnnulee},
+	CommandeEnLivraison:   {CommandeLivree},
+	CommandeLivree:        {},
+	CommandeAnnulee:       {},
+}
+
+// StatutCommandeValide indique si le statut fait partie du cycle de vie des commandes.
+func StatutCommandeValide(status string) bool {
+	_, ok := transitionsCommande[status]
+	return ok
+}
+
+// TransitionCommandeAutorisee indique si une commande peut passer du statut "from" au statut "to".
+func TransitionCommandeAutorisee(from, to string) bool {
+	for _, s := range transitionsCommande[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatutCommandeSuivant renvoie l'étape normale suivant "from" (chaîne vide si la commande est terminée).
+func StatutCommandeSuivant(from string) string {
+	if next := transitionsCommande[from]; len(next) > 0 && next[0] != CommandeAnnulee {
+		return next[0]
+	}
+	return ""
+}
+
 // Commande struct (Modèle de commande pour la base de données)
 type Commande struct {
-	ID          string    `gorm:"type:uuid;primaryKey" json:"ID"`      // ID commande (UUID string)
-	ClientID    string    `gorm:"type:uuid;not null" json:"client_id"` // ID client (clé étrangère)
-	OrderDate   time.Time `json:"order_date"`
-	TotalAmount float64   `json:"total_amount"`
-	Status      string    `json:"status"` // Statut de la commande
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID             string                     `gorm:"type:uuid;primaryKey" json:"ID"`      // ID commande (UUID string)
+	ClientID       string                     `gorm:"type:uuid;not null" json:"client_id"` // ID client (clé étrangère)
+	OrderDate      time.Time                  `json:"order_date"`
+	TotalAmount    float64                    `json:"total_amount"`
+	AmountPaid     float64                    `gorm:"default:0" json:"amount_paid"`     // Montant encaissé, net des remboursements
+	AmountRefunded float64                    `gorm:"default:0" json:"amount_refunded"` // Montant total remboursé
+	Status         string                     `json:"status"`                           // Statut de la commande
+	CreatedAt      time.Time                  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time                  `gorm:"autoUpdateTime" json:"updated_at"`
+	Lignes         []CommandePlat             `gorm:"foreignKey:CommandeID" json:"lignes"`               // Plats commandés
+	Historique     []CommandeStatutHistorique `gorm:"foreignKey:CommandeID" json:"historique,omitempty"` // Changements de statut
 }
 
 // BeforeCreate hook pour Commande (Génère un UUID avant la création)
@@ -145,16 +411,63 @@ func (c *Commande) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// CommandeStatutHistorique struct (Historique horodaté des changements de statut d'une commande)
+type CommandeStatutHistorique struct {
+	ID         string    `gorm:"type:uuid;primaryKey" json:"ID"`              // ID de l'entrée (UUID string)
+	CommandeID string    `gorm:"type:uuid;not null;index" json:"commande_id"` // ID commande (clé étrangère)
+	FromStatus string    `json:"from_status"`                                 // Statut précédent (vide à la création)
+	ToStatus   string    `gorm:"not null" json:"to_status"`                   // Nouveau statut
+	ActorType  string    `gorm:"type:varchar(20);not null" json:"actor_type"` // "client", "admin" ou "system"
+	ActorID    string    `json:"actor_id"`                                    // ID de l'auteur du changement
+	Note       string    `json:"note"`                                        // Commentaire optionnel
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook pour CommandeStatutHistorique (Génère un UUID avant la création)
+func (h *CommandeStatutHistorique) BeforeCreate(tx *gorm.DB) (err error) {
+	if h.ID == "" {
+		h.ID = uuid.New().String()
+	}
+	return
+}
+
+// Méthodes de paiement acceptées
+const (
+	PaiementCarte            = "carte"             // Paiement en ligne via la passerelle
+	PaiementEspecesLivraison = "especes_livraison" // Paiement à la livraison, sans passerelle
+	PaiementComptoir         = "comptoir"          // Paiement au comptoir, sans passerelle
+)
+
+// Statuts possibles d'un paiement
+const (
+	PaiementEnAttente              = "En attente"
+	PaiementPaye                   = "Payé"
+	PaiementEchoue                 = "Échoué"
+	PaiementPartiellementRembourse = "Partiellement remboursé"
+	PaiementRembourse              = "Remboursé"
+	PaiementRemplace               = "Remplacé" // Paiement en attente remplacé par un nouveau paiement de la commande
+)
+
+// MethodePaiementValide indique si la méthode de paiement est acceptée.
+func MethodePaiementValide(method string) bool {
+	return method == PaiementCarte || method == PaiementEspecesLivraison || method == PaiementComptoir
+}
+
 // Paiement struct (Modèle de paiement pour la base de données)
 type Paiement struct {
-	ID          string    `gorm:"type:uuid;primaryKey" json:"ID"`        // ID paiement (UUID string)
-	CommandeID  string    `gorm:"type:uuid;not null" json:"commande_id"` // ID commande (clé étrangère)
-	Amount      float64   `json:"amount"`
-	PaymentDate time.Time `json:"payment_date"`
-	Method      string    `json:"method"` // Méthode de paiement
-	Status      string    `json:"status"` // Statut du paiement
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID             string          `gorm:"type:uuid;primaryKey" json:"ID"`        // ID paiement (UUID string)
+	CommandeID     string          `gorm:"type:uuid;not null" json:"commande_id"` // ID commande (clé étrangère)
+	Amount         float64         `json:"amount"`
+	PaymentDate    time.Time       `json:"payment_date"`
+	Method         string          `json:"method"`                           // Méthode de paiement
+	Status         string          `json:"status"`                           // Statut du paiement
+	Provider       string          `json:"provider"`                         // Prestataire de paiement (vide pour espèces/comptoir)
+	ProviderRef    string          `gorm:"index" json:"provider_ref"`        // Référence du paiement chez le prestataire
+	CheckoutURL    string          `json:"checkout_url,omitempty"`           // Page de paiement du prestataire, pour reprendre un paiement en attente
+	RefundedAmount float64         `gorm:"default:0" json:"refunded_amount"` // Montant déjà remboursé sur ce paiement
+	Remboursements []Remboursement `gorm:"foreignKey:PaiementID" json:"remboursements,omitempty"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // BeforeCreate hook pour Paiement (Génère un UUID avant la création)
@@ -165,14 +478,70 @@ func (p *Paiement) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// Remboursement struct (Remboursement total ou partiel d'un paiement encaissé)
+type Remboursement struct {
+	ID          string    `gorm:"type:uuid;primaryKey" json:"ID"`              // ID remboursement (UUID string)
+	PaiementID  string    `gorm:"type:uuid;not null;index" json:"paiement_id"` // Paiement d'origine
+	CommandeID  string    `gorm:"type:uuid;not null;index" json:"commande_id"` // Commande concernée
+	Amount      float64   `gorm:"not null" json:"amount"`
+	Reason      string    `json:"reason"`                             // Motif (ex: plat manquant)
+	ProviderRef string    `json:"provider_ref"`                       // Référence du remboursement chez le prestataire
+	ActorType   string    `gorm:"type:varchar(20)" json:"actor_type"` // Auteur du remboursement
+	ActorID     string    `json:"actor_id"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// BeforeCreate hook pour Remboursement (Génère un UUID avant la création)
+func (rb *Remboursement) BeforeCreate(tx *gorm.DB) (err error) {
+	if rb.ID == "" {
+		rb.ID = uuid.New().String()
+	}
+	return
+}
+
+// EvenementWebhook struct (Événement reçu d'un prestataire de paiement, pour ne le traiter qu'une fois)
+type EvenementWebhook struct {
+	ID          string    `gorm:"type:uuid;primaryKey" json:"ID"`
+	Provider    string    `gorm:"not null;uniqueIndex:idx_webhook_provider_event" json:"provider"`
+	EventID     string    `gorm:"not null;uniqueIndex:idx_webhook_provider_event" json:"event_id"` // ID de l'événement chez le prestataire
+	Type        string    `json:"type"`
+	ProviderRef string    `json:"provider_ref"`
+	Status      string    `gorm:"type:varchar(20);default:'Traité'" json:"status"` // Traité, ou Ignoré (sans effet, non rejouable)
+	Note        string    `json:"note"`                                            // Raison d'un événement ignoré
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Statuts d'un événement de webhook enregistré
+const (
+	WebhookTraite = "Traité"
+	WebhookIgnore = "Ignoré"
+)
+
+// BeforeCreate hook pour EvenementWebhook (Génère un UUID avant la création)
+func (e *EvenementWebhook) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+// Types de notifications (objet concerné par la notification)
+const (
+	NotificationCommande    = "commande"
+	NotificationReservation = "reservation"
+	NotificationCompte      = "compte" // Sécurité du compte (verrouillage, ...)
+)
+
 // Notification struct (Modèle de notification pour la base de données)
 type Notification struct {
-	ID        string    `gorm:"type:uuid;primaryKey" json:"ID"`      // ID notification (UUID string)
-	ClientID  string    `gorm:"type:uuid;not null" json:"client_id"` // ID client (clé étrangère)
-	Message   string    `json:"message"`
-	IsRead    bool      `gorm:"default:false" json:"is_read"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          string    `gorm:"type:uuid;primaryKey" json:"ID"`            // ID notification (UUID string)
+	ClientID    string    `gorm:"type:uuid;not null;index" json:"client_id"` // ID client (clé étrangère)
+	Type        string    `gorm:"type:varchar(20)" json:"type"`              // Objet concerné (commande, réservation)
+	ReferenceID string    `gorm:"type:uuid" json:"reference_id"`             // ID de la commande ou de la réservation
+	Message     string    `json:"message"`
+	IsRead      bool      `gorm:"default:false" json:"is_read"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // BeforeCreate hook pour Notification (Génère un UUID avant la création)
@@ -183,11 +552,75 @@ func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// Statuts d'un message sortant (email, SMS)
+const (
+	MessageEnAttente = "En attente"
+	MessageEnvoye    = "Envoyé"
+	MessageEchoue    = "Échoué" // Abandonné après le nombre maximal d'essais
+)
+
+// MessageSortant struct (File persistante des emails et SMS à envoyer, avec réessais)
+type MessageSortant struct {
+	ID            string     `gorm:"type:uuid;primaryKey" json:"ID"`
+	Channel       string     `gorm:"type:varchar(10);not null" json:"channel"` // email ou sms
+	Recipient     string     `gorm:"not null" json:"recipient"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Kind          string     `json:"kind"`         // Nature du message (ex: reservation_confirmee)
+	ReferenceID   string     `json:"reference_id"` // ID de l'objet concerné
+	Status        string     `gorm:"type:varchar(20);default:'En attente';index:idx_message_a_envoyer" json:"status"`
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_message_a_envoyer" json:"next_attempt_at"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate hook pour MessageSortant (Génère un UUID avant la création)
+func (m *MessageSortant) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return
+}
+
+// ModeleMessage struct (Texte d'un message envoyé aux clients, modifiable par l'équipe)
+// Subject et Body utilisent la syntaxe text/template (ex: "Bonjour {{.Nom}}").
+type ModeleMessage struct {
+	ID        string    `gorm:"type:uuid;primaryKey" json:"ID"`
+	Key       string    `gorm:"not null;uniqueIndex:idx_modele_key_lang" json:"key"`
+	Lang      string    `gorm:"type:varchar(5);not null;uniqueIndex:idx_modele_key_lang" json:"lang"`
+	Subject   string    `json:"subject"`
+	Body      string    `gorm:"not null" json:"body"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate hook pour ModeleMessage (Génère un UUID avant la création)
+func (m *ModeleMessage) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return
+}
+
 // CommandePlat (Table de jointure pour relation Many-to-Many entre Commande et Plat)
+// Le prix, le nom et la catégorie du plat sont copiés au moment de la commande, pour que
+// l'historique reste exact si le plat est modifié ou supprimé du menu.
 type CommandePlat struct {
-	CommandeID string `gorm:"type:uuid;primaryKey" json:"commande_id"` // ID commande (clé primaire/étrangère)
-	PlatID     string `gorm:"type:uuid;primaryKey" json:"plat_id"`     // ID plat (clé primaire/étrangère)
-	Quantity   int    `json:"quantity"`
+	CommandeID   string     `gorm:"type:uuid;primaryKey" json:"commande_id"` // ID commande (clé primaire/étrangère)
+	PlatID       string     `gorm:"type:uuid;primaryKey" json:"plat_id"`     // ID plat (clé primaire/étrangère)
+	Quantity     int        `json:"quantity"`
+	UnitPrice    float64    `gorm:"not null;default:0" json:"unit_price"` // Prix unitaire au moment de la commande
+	PlatName     string     `json:"plat_name"`                            // Nom du plat au moment de la commande
+	PlatCategory string     `json:"plat_category"`                        // Catégorie du plat au moment de la commande
+	ReadyAt      *time.Time `json:"ready_at"`                             // Heure à laquelle la cuisine a marqué la ligne prête
+}
+
+// Montant renvoie le total de la ligne à partir du prix enregistré à la commande.
+func (cp CommandePlat) Montant() float64 {
+	return cp.UnitPrice * float64(cp.Quantity)
 }
 
 // PanierPlat (Table de jointure pour relation Many-to-Many entre Panier et Plat)
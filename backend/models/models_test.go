@@ -0,0 +1,70 @@
+package models
+
+import "testing"
+
+func TestTransitionCommandeAutorisee(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{CommandeEnAttente, CommandeConfirmee, true},
+		{CommandeEnAttente, CommandeAnnulee, true},
+		{CommandeEnAttente, CommandeEnPreparation, false},
+		{CommandeConfirmee, CommandeEnPreparation, true},
+		{CommandeConfirmee, CommandeLivree, false},
+		{CommandeEnPreparation, CommandePrete, true},
+		{CommandeEnPreparation, CommandeConfirmee, false},
+		{CommandePrete, CommandeEnLivraison, true},
+		{CommandePrete, CommandeLivree, true}, // Retrait au comptoir
+		{CommandePrete, CommandeAnnulee, true},
+		{CommandeEnLivraison, CommandeLivree, true},
+		{CommandeEnLivraison, CommandeAnnulee, false},
+		{CommandeLivree, CommandeAnnulee, false},
+		{CommandeAnnulee, CommandeEnAttente, false},
+		{CommandeEnAttente, CommandeEnAttente, false},
+		{"En Attente", CommandeConfirmee, false}, // Ancien libellé du frontend
+		{CommandeConfirmee, "Terminée", false},
+	}
+	for _, tt := range tests {
+		if got := TransitionCommandeAutorisee(tt.from, tt.to); got != tt.want {
+			t.Errorf("TransitionCommandeAutorisee(%q, %q) = %v, attendu %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestStatutCommandeSuivant(t *testing.T) {
+	tests := []struct {
+		from, want string
+	}{
+		{CommandeEnAttente, CommandeConfirmee},
+		{CommandeConfirmee, CommandeEnPreparation},
+		{CommandeEnPreparation, CommandePrete},
+		{CommandePrete, CommandeEnLivraison},
+		{CommandeEnLivraison, CommandeLivree},
+		{CommandeLivree, ""},
+		{CommandeAnnulee, ""},
+		{"inconnu", ""},
+	}
+	for _, tt := range tests {
+		if got := StatutCommandeSuivant(tt.from); got != tt.want {
+			t.Errorf("StatutCommandeSuivant(%q) = %q, attendu %q", tt.from, got, tt.want)
+		}
+	}
+}
+
+// Chaque statut cible d'une transition doit lui-même faire partie du cycle de vie.
+func TestTransitionsCommandeFermees(t *testing.T) {
+	for from, targets := range transitionsCommande {
+		for _, to := range targets {
+			if !StatutCommandeValide(to) {
+				t.Errorf("transition %q -> %q vers un statut inconnu", from, to)
+			}
+			if to == from {
+				t.Errorf("transition %q -> %q vers le même statut", from, to)
+			}
+		}
+	}
+	if StatutCommandeValide("Terminée") {
+		t.Error("StatutCommandeValide(\"Terminée\") = true, attendu false")
+	}
+}
@@ -0,0 +1,78 @@
+package payments
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FakeGateway est une passerelle factice: elle accepte tous les paiements et reçoit
+// des webhooks JSON signés localement avec le secret configuré:
+//
+//	{"event_id": "evt_1", "type": "payment.succeeded", "provider_ref": "fake_..."}
+//
+// L'heure d'envoi (secondes Unix) est envoyée dans l'en-tête X-Signature-Timestamp et la signature
+// de "horodatage.corps" dans l'en-tête X-Signature (voir Sign), par exemple:
+//
+//	TS=$(date +%s)
+//	echo -n "$TS.$BODY" | openssl dgst -sha256 -hmac "$PAYMENT_WEBHOOK_SECRET"
+type FakeGateway struct {
+	WebhookSecret string
+	Now           func() time.Time // Horloge utilisée pour vérifier l'horodatage (time.Now par défaut)
+}
+
+// NewFakeGateway crée une nouvelle passerelle factice.
+func NewFakeGateway(webhookSecret string) *FakeGateway {
+	return &FakeGateway{WebhookSecret: webhookSecret}
+}
+
+// Name renvoie l'identifiant du prestataire factice.
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+// CreatePayment génère une référence de paiement sans appel externe.
+func (g *FakeGateway) CreatePayment(req Request) (Intent, error) {
+	if req.Amount <= 0 {
+		return Intent{}, fmt.Errorf("montant invalide: %.2f", req.Amount)
+	}
+	return Intent{ProviderRef: "fake_" + uuid.New().String()}, nil
+}
+
+// ParseWebhook vérifie la signature HMAC-SHA256 et l'horodatage du corps puis lit l'événement.
+func (g *FakeGateway) ParseWebhook(body []byte, header http.Header) (WebhookEvent, error) {
+	if g.WebhookSecret == "" {
+		return WebhookEvent{}, ErrSignatureInvalide
+	}
+	now := time.Now
+	if g.Now != nil {
+		now = g.Now
+	}
+	if err := Verify(g.WebhookSecret, body, header.Get(SignatureHeader), header.Get(TimestampHeader), now()); err != nil {
+		return WebhookEvent{}, err
+	}
+
+	var payload struct {
+		EventID     string `json:"event_id"`
+		Type        string `json:"type"`
+		ProviderRef string `json:"provider_ref"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return WebhookEvent{}, ErrWebhookInvalide
+	}
+	if payload.EventID == "" || payload.Type == "" {
+		return WebhookEvent{}, ErrWebhookInvalide
+	}
+	return WebhookEvent{ID: payload.EventID, Type: payload.Type, ProviderRef: payload.ProviderRef}, nil
+}
+
+// Refund accepte tous les remboursements d'un montant positif.
+func (g *FakeGateway) Refund(providerRef string, amount float64) (string, error) {
+	if providerRef == "" || amount <= 0 {
+		return "", fmt.Errorf("remboursement invalide (réf: '%s', montant: %.2f)", providerRef, amount)
+	}
+	return "fake_refund_" + uuid.New().String(), nil
+}
@@ -0,0 +1,55 @@
+// Package payments définit l'interface des prestataires de paiement en ligne
+// et fournit une passerelle factice pour le développement et les tests.
+package payments
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Types d'événements de webhook reconnus
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+)
+
+var (
+	// ErrWebhookInvalide est renvoyée quand le contenu du webhook ne peut pas être interprété.
+	ErrWebhookInvalide = errors.New("webhook de paiement invalide")
+	// ErrSignatureInvalide est renvoyée quand la signature du webhook ne correspond pas au contenu.
+	ErrSignatureInvalide = errors.New("signature de webhook invalide")
+)
+
+// Request décrit un paiement à initier auprès d'une passerelle.
+type Request struct {
+	PaiementID  string
+	CommandeID  string
+	Amount      float64
+	Currency    string
+	Description string
+}
+
+// Intent est la réponse de la passerelle à l'initiation d'un paiement.
+type Intent struct {
+	ProviderRef string // Référence du paiement chez le prestataire
+	CheckoutURL string // Page de paiement où rediriger le client (optionnelle)
+}
+
+// WebhookEvent est un événement envoyé par le prestataire, après vérification de sa signature.
+type WebhookEvent struct {
+	ID          string // Identifiant unique de l'événement chez le prestataire (rejoué à l'identique en cas de réessai)
+	Type        string // EventPaymentSucceeded, EventPaymentFailed ou autre type ignoré
+	ProviderRef string // Référence du paiement concerné
+}
+
+// Gateway est l'interface que doit implémenter chaque prestataire de paiement.
+type Gateway interface {
+	// Name renvoie l'identifiant du prestataire, enregistré sur le Paiement.
+	Name() string
+	// CreatePayment initie un paiement chez le prestataire.
+	CreatePayment(req Request) (Intent, error)
+	// ParseWebhook vérifie la signature du webhook envoyé par le prestataire et en extrait l'événement.
+	ParseWebhook(body []byte, header http.Header) (WebhookEvent, error)
+	// Refund rembourse tout ou partie d'un paiement et renvoie la référence du remboursement.
+	Refund(providerRef string, amount float64) (string, error)
+}
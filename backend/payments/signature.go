@@ -0,0 +1,51 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// En-têtes HTTP portant la signature des webhooks et l'heure de leur envoi.
+const (
+	SignatureHeader = "X-Signature"
+	TimestampHeader = "X-Signature-Timestamp" // Secondes Unix
+)
+
+// SignatureTolerance est l'écart maximal accepté entre l'horodatage signé et l'heure du serveur:
+// un webhook capturé ne peut pas être rejoué au-delà.
+const SignatureTolerance = 5 * time.Minute
+
+// ErrSignaturePerimee est renvoyée quand l'horodatage signé est hors de la fenêtre acceptée.
+var ErrSignaturePerimee = fmt.Errorf("%w: horodatage hors de la fenêtre acceptée", ErrSignatureInvalide)
+
+// Sign calcule la signature HMAC-SHA256 (hexadécimale) de "horodatage.corps" avec le secret partagé.
+// Utile pour générer localement des webhooks signés.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify vérifie la signature reçue (comparaison en temps constant, préfixe "sha256=" accepté)
+// puis que l'horodatage est à moins de SignatureTolerance de now.
+func Verify(secret string, body []byte, signature, timestamp string, now time.Time) error {
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return ErrSignatureInvalide
+	}
+	expected := Sign(secret, ts, body)
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrSignatureInvalide
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrSignaturePerimee
+	}
+	return nil
+}
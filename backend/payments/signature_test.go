@@ -0,0 +1,93 @@
+package payments
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "secret-de-test"
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"event_id":"evt_1","type":"payment.succeeded","provider_ref":"fake_1"}`)
+	ts := now.Unix()
+	good := Sign(testSecret, ts, body)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+		timestamp string
+		wantErr   error
+	}{
+		{"signature valide", body, good, strconv.FormatInt(ts, 10), nil},
+		{"préfixe sha256= et majuscules", body, "sha256=" + strings.ToUpper(good), strconv.FormatInt(ts, 10), nil},
+		{"légèrement en retard", body, Sign(testSecret, ts-60, body), strconv.FormatInt(ts-60, 10), nil},
+		{"mauvaise signature", body, strings.Repeat("0", 64), strconv.FormatInt(ts, 10), ErrSignatureInvalide},
+		{"autre secret", body, Sign("autre", ts, body), strconv.FormatInt(ts, 10), ErrSignatureInvalide},
+		{"corps modifié", []byte(`{"event_id":"evt_2"}`), good, strconv.FormatInt(ts, 10), ErrSignatureInvalide},
+		{"horodatage modifié", body, good, strconv.FormatInt(ts+1, 10), ErrSignatureInvalide},
+		{"signature vide", body, "", strconv.FormatInt(ts, 10), ErrSignatureInvalide},
+		{"horodatage absent", body, good, "", ErrSignatureInvalide},
+		{"horodatage illisible", body, good, "hier", ErrSignatureInvalide},
+		{"horodatage périmé", body, Sign(testSecret, ts-3600, body), strconv.FormatInt(ts-3600, 10), ErrSignaturePerimee},
+		{"horodatage dans le futur", body, Sign(testSecret, ts+3600, body), strconv.FormatInt(ts+3600, 10), ErrSignaturePerimee},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(testSecret, tt.body, tt.signature, tt.timestamp, now)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() = %v, attendu nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() = %v, attendu %v", err, tt.wantErr)
+			}
+			// Toute erreur de vérification est une signature invalide pour le handler (401)
+			if !errors.Is(err, ErrSignatureInvalide) {
+				t.Errorf("Verify() = %v, n'est pas une ErrSignatureInvalide", err)
+			}
+		})
+	}
+}
+
+func TestFakeGatewayParseWebhook(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	g := &FakeGateway{WebhookSecret: testSecret, Now: func() time.Time { return now }}
+	signed := func(body string, ts int64) http.Header {
+		h := http.Header{}
+		h.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+		h.Set(SignatureHeader, Sign(testSecret, ts, []byte(body)))
+		return h
+	}
+	valid := `{"event_id":"evt_1","type":"payment.succeeded","provider_ref":"fake_1"}`
+
+	event, err := g.ParseWebhook([]byte(valid), signed(valid, now.Unix()))
+	if err != nil {
+		t.Fatalf("ParseWebhook() = %v", err)
+	}
+	want := WebhookEvent{ID: "evt_1", Type: EventPaymentSucceeded, ProviderRef: "fake_1"}
+	if event != want {
+		t.Errorf("ParseWebhook() = %+v, attendu %+v", event, want)
+	}
+
+	if _, err := g.ParseWebhook([]byte(valid), signed(valid, now.Add(-time.Hour).Unix())); !errors.Is(err, ErrSignaturePerimee) {
+		t.Errorf("webhook rejoué une heure plus tard: %v, attendu %v", err, ErrSignaturePerimee)
+	}
+	if _, err := g.ParseWebhook([]byte(valid), http.Header{}); !errors.Is(err, ErrSignatureInvalide) {
+		t.Errorf("webhook non signé: %v, attendu %v", err, ErrSignatureInvalide)
+	}
+	incomplete := `{"type":"payment.succeeded"}`
+	if _, err := g.ParseWebhook([]byte(incomplete), signed(incomplete, now.Unix())); !errors.Is(err, ErrWebhookInvalide) {
+		t.Errorf("webhook sans event_id: %v, attendu %v", err, ErrWebhookInvalide)
+	}
+	if _, err := (&FakeGateway{}).ParseWebhook([]byte(valid), signed(valid, now.Unix())); !errors.Is(err, ErrSignatureInvalide) {
+		t.Errorf("passerelle sans secret: %v, attendu %v", err, ErrSignatureInvalide)
+	}
+}
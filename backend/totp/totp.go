@@ -0,0 +1,115 @@
+// Package totp implémente les codes à usage unique basés sur le temps (RFC 6238),
+// compatibles avec les applications d'authentification (Google Authenticator, Authy, ...).
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Paramètres des codes, ceux attendus par défaut par les applications d'authentification.
+const (
+	Period = 30 // Durée de validité d'un code, en secondes
+	Digits = 6  // Nombre de chiffres d'un code
+)
+
+// ErrSecretInvalide est renvoyée pour un secret qui n'est pas en base32.
+var ErrSecretInvalide = errors.New("secret TOTP invalide")
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret génère un secret aléatoire de 160 bits, encodé en base32 (sans remplissage).
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// decodeSecret lit un secret base32, en tolérant les espaces, minuscules et le remplissage.
+func decodeSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := encoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrSecretInvalide
+	}
+	return key, nil
+}
+
+// hotp calcule le code du compteur donné (RFC 4226, HMAC-SHA1).
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
+
+// Step renvoie le pas de temps (compteur) correspondant à un instant.
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code renvoie le code valable à l'instant donné.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(Step(t))), nil
+}
+
+// Validate vérifie un code à l'instant donné, en acceptant skew pas de temps d'écart
+// (décalage d'horloge du téléphone). Elle renvoie le pas de temps du code reconnu,
+// à enregistrer pour refuser qu'un même code serve deux fois.
+func Validate(secret, code string, t time.Time, skew int) (int64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != Digits {
+		return 0, false
+	}
+	now := Step(t)
+	for i := -skew; i <= skew; i++ {
+		step := now + int64(i)
+		if step < 0 {
+			continue
+		}
+		if hmac.Equal([]byte(hotp(key, uint64(step))), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// URI renvoie l'adresse otpauth:// à afficher en QR code pour enregistrer le secret
+// dans une application d'authentification.
+func URI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(Period))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
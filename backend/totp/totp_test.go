@@ -0,0 +1,104 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret est le secret SHA1 des vecteurs de test de la RFC 6238 ("12345678901234567890"), en base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238(t *testing.T) {
+	// Vecteurs de l'annexe B de la RFC 6238 (SHA1, codes à 8 chiffres): avec Digits = 6,
+	// le code est formé des 6 derniers chiffres.
+	tests := []struct {
+		unix int64
+		rfc  string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		want := tt.rfc[len(tt.rfc)-Digits:]
+		got, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("Code(T=%d): %v", tt.unix, err)
+		}
+		if got != want {
+			t.Errorf("Code(T=%d) = %s, attendu %s", tt.unix, got, want)
+		}
+		// Le secret est lu en tolérant minuscules, espaces et remplissage
+		loose := strings.ToLower(rfcSecret[:8]) + " " + rfcSecret[8:] + "===="
+		if got, _ := Code(loose, time.Unix(tt.unix, 0)); got != want {
+			t.Errorf("Code(T=%d) avec un secret mal formaté = %s, attendu %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := Step(now)
+	codeAt := func(offset int) string {
+		code, err := Code(rfcSecret, now.Add(time.Duration(offset)*Period*time.Second))
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"code courant", codeAt(0), 1, step, true},
+		{"code courant sans tolérance", codeAt(0), 0, step, true},
+		{"code avec espaces", codeAt(0)[:3] + " " + codeAt(0)[3:] + " ", 1, step, true},
+		{"pas précédent", codeAt(-1), 1, step - 1, true},
+		{"pas suivant", codeAt(1), 1, step + 1, true},
+		{"pas précédent sans tolérance", codeAt(-1), 0, 0, false},
+		{"deux pas de retard", codeAt(-2), 1, 0, false},
+		{"deux pas d'avance", codeAt(2), 1, 0, false},
+		{"deux pas de retard, tolérance 2", codeAt(-2), 2, step - 2, true},
+		{"mauvais code", "000000", 1, 0, false},
+		{"code trop court", codeAt(0)[:5], 1, 0, false},
+		{"code RFC à 8 chiffres", "89005924", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Validate(rfcSecret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate(%q, skew %d) = (%d, %v), attendu (%d, %v)", tt.code, tt.skew, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+
+	if _, ok := Validate("pas du base32!", codeAt(0), now, 1); ok {
+		t.Error("Validate() accepte un secret invalide")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	key, err := decodeSecret(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q: %d octets (%v), attendu 20", secret, len(key), err)
+	}
+	other, _ := GenerateSecret()
+	if other == secret {
+		t.Error("GenerateSecret() renvoie deux fois le même secret")
+	}
+	if _, err := Code("", time.Now()); err != ErrSecretInvalide {
+		t.Errorf("Code() avec un secret vide: %v, attendu %v", err, ErrSecretInvalide)
+	}
+}
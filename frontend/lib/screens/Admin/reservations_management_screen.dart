@@ -2,6 +2,7 @@ import 'package:flutter/material.dart';
 import 'package:http/http.dart' as http; // Importez le package http
 import 'dart:convert'; // Nécessaire pour encoder/décoder JSON
 import 'package:intl/intl.dart'; // Pour formater les dates reçues du backend
+import 'package:restaurant_app/services/auth_service.dart'; // Jeton d'accès de l'administrateur connecté
 
 class ReservationsManagementScreen extends StatefulWidget {
   const ReservationsManagementScreen({Key? key}) : super(key: key);
@@ -19,8 +20,6 @@ class _ReservationsManagementScreenState
   bool _isLoading = true; // Pour afficher un indicateur de chargement
   String? _errorMessage; // Pour afficher les erreurs
 
-  // REMPLACEZ CECI AVEC VOTRE VRAI ADMIN_TOKEN DE VOTRE FICHIER .env GO
-  final String _adminToken = "MonSuperTokenAdminPourRestoApp2025!"; // <--- IMPORTANT : Vérifiez cette valeur
   final String _apiUrl = "http://192.168.11.105:8080/admin/reservations"; // L'URL de votre endpoint admin
 
   @override
@@ -48,7 +47,7 @@ class _ReservationsManagementScreenState
         Uri.parse(_apiUrl),
         headers: {
           'Content-Type': 'application/json; charset=UTF-8',
-          'X-Admin-Token': _adminToken, // Envoyez le token d'administration ici
+          ...await AuthService.authHeader(), // Jeton d'accès obtenu à la connexion
         },
       );
 
@@ -88,7 +87,7 @@ class _ReservationsManagementScreenState
         Uri.parse("http://192.168.11.105:8080/api/reservations"), // Utilisez l'endpoint client pour la création
         headers: {
           'Content-Type': 'application/json; charset=UTF-8',
-          // Pas besoin de X-Admin-Token pour l'endpoint client
+          // Pas besoin du jeton d'administration pour l'endpoint client
         },
         body: jsonEncode(data),
       );
@@ -130,7 +129,7 @@ class _ReservationsManagementScreenState
         Uri.parse('$_apiUrl/$id'), // URL pour PUT /admin/reservations/{id}
         headers: {
           'Content-Type': 'application/json; charset=UTF-8',
-          'X-Admin-Token': _adminToken, // Envoyez le token d'administration
+          ...await AuthService.authHeader(), // Jeton d'accès obtenu à la connexion
         },
         body: jsonEncode(data), // Les données à mettre à jour
       );
@@ -170,9 +169,7 @@ class _ReservationsManagementScreenState
     try {
       final response = await http.delete(
         Uri.parse('$_apiUrl/$id'), // URL pour DELETE /admin/reservations/{id}
-        headers: {
-          'X-Admin-Token': _adminToken, // Envoyez le token d'administration
-        },
+        headers: await AuthService.authHeader(), // Jeton d'accès obtenu à la connexion
       );
 
       if (response.statusCode == 204) { // 204 No Content pour une suppression réussie
@@ -2,7 +2,7 @@ import 'dart:convert';
 import 'package:http/http.dart' as http;
 import 'package:restaurant_app/models/client_model.dart'; // Ensure this path is correct, it was client_model.dart before
 import 'package:restaurant_app/models/reservation_model.dart';
-import 'package:shared_preferences/shared_preferences.dart'; // Pour stocker le token client si vous l'utilisez
+import 'package:restaurant_app/services/auth_service.dart';
 
 class AdService {
   // --- Configuration du Backend ---
@@ -10,21 +10,18 @@ class AdService {
   static const String _baseUrl = 'http://192.168.11.105:8080';
   //static const String _baseUrl = 'http://192.168.1.XX:8080'; // Exemple pour IP locale, à adapter
 
-  // IMPORTANT : Ce token doit CORRESPONDRE exactement à celui défini dans votre fichier .env du backend
-  static const String _adminToken = 'MonSuperTokenAdminPourRestoApp2025!';
-  // N'oubliez PAS de remplacer 'VotreTokenAdminSecretEtUniqueIci_12345' par votre vrai token !
-
   // --- En-têtes HTTP communs ---
   Map<String, String> _headers() {
     return {'Content-Type': 'application/json', 'Accept': 'application/json'};
   }
 
   // En-têtes spécifiques pour les requêtes administrateur
-  Map<String, String> _adminHeaders() {
+  // (jeton d'accès de l'administrateur connecté, obtenu via /login)
+  Future<Map<String, String>> _adminHeaders() async {
     return {
       'Content-Type': 'application/json',
       'Accept': 'application/json',
-      'X-Admin-Token': _adminToken, // Le token secret est envoyé ici
+      ...await AuthService.authHeader(),
     };
   }
 
@@ -147,7 +144,7 @@ class AdService {
     try {
       final response = await http.get(
         Uri.parse(url),
-        headers: _adminHeaders(), // Utilise les en-têtes avec le token admin
+        headers: await _adminHeaders(), // Utilise les en-têtes avec le token admin
       );
 
       print(
@@ -168,9 +165,9 @@ class AdService {
         return reservations;
       } else if (response.statusCode == 403) {
         print(
-          'DEBUG AdService: Accès admin refusé. Vérifiez votre ADMIN_TOKEN. Corps: ${response.body}',
+          'DEBUG AdService: Accès admin refusé. Vérifiez que l'administrateur est connecté. Corps: ${response.body}',
         );
-        throw Exception('Accès non autorisé. Vérifiez votre token admin.');
+        throw Exception('Accès non autorisé. Reconnectez-vous avec un compte administrateur.');
       } else {
         print(
           'DEBUG AdService: Échec du chargement des réservations admin (code: ${response.statusCode}, corps: ${response.body})',
@@ -194,7 +191,7 @@ class AdService {
     try {
       final response = await http.put(
         url,
-        headers: _adminHeaders(),
+        headers: await _adminHeaders(),
         body: json.encode({'id': id, 'status': newStatus}),
       );
 
@@ -238,7 +235,7 @@ class AdService {
     try {
       final response = await http.delete(
         url,
-        headers: _adminHeaders(), // Utilise les en-têtes admin
+        headers: await _adminHeaders(), // Utilise les en-têtes admin
       );
 
       print(
@@ -284,7 +281,7 @@ class AdService {
     try {
       final response = await http.put(
         url,
-        headers: _adminHeaders(), // Utilise les en-têtes admin
+        headers: await _adminHeaders(), // Utilise les en-têtes admin
         body: json.encode(reservation.toMap()), // Envoyez l'objet complet
       );
 
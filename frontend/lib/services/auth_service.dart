@@ -10,6 +10,22 @@ class AuthService {
   final Uri signupUrl = Uri.parse('http://192.168.11.105:8080/signup');
   final Uri loginUrl = Uri.parse('http://192.168.11.105:8080/login');
 
+  // Clés SharedPreferences des jetons renvoyés par /login
+  static const String accessTokenKey = 'accessToken';
+  static const String refreshTokenKey = 'refreshToken';
+
+  /// Jeton d'accès de la session en cours (null si personne n'est connecté).
+  static Future<String?> accessToken() async {
+    final prefs = await SharedPreferences.getInstance();
+    return prefs.getString(accessTokenKey);
+  }
+
+  /// En-tête Authorization à joindre aux requêtes protégées (vide si personne n'est connecté).
+  static Future<Map<String, String>> authHeader() async {
+    final token = await accessToken();
+    return token == null ? {} : {'Authorization': 'Bearer $token'};
+  }
+
   // Fonction de connexion
   Future<Client?> login(String email, String motDePasse) async {
     final url = loginUrl;
@@ -40,6 +56,9 @@ class AuthService {
           final prefs = await SharedPreferences.getInstance();
           await prefs.setString('userEmail', loggedInClient.email);
           await prefs.setBool('isAdmin', loggedInClient.isAdmin);
+          // Jetons de session: le jeton d'accès est envoyé en Bearer aux routes /admin
+          await prefs.setString(accessTokenKey, data['access_token'] as String);
+          await prefs.setString(refreshTokenKey, data['refresh_token'] as String);
           // CHANGEMENT ICI: Stocke l'ID comme String si non nul
           if (loggedInClient.id != null) {
             await prefs.setString('userId', loggedInClient.id!);
@@ -110,6 +129,8 @@ class AuthService {
     final prefs = await SharedPreferences.getInstance();
     await prefs.remove('userEmail');
     await prefs.remove('isAdmin');
+    await prefs.remove(accessTokenKey);
+    await prefs.remove(refreshTokenKey);
     // CHANGEMENT ICI: Retire userId de SharedPreferences
     await prefs.remove('userId');
     // return true; // Plus nécessaire car Future<void>
@@ -3,21 +3,20 @@
 import 'dart:convert';
 import 'package:http/http.dart' as http;
 import 'package:restaurant_app/models/client_model.dart'; // Assurez-vous d'importer votre modèle Client
+import 'package:restaurant_app/services/auth_service.dart';
 
 /// Service pour interagir avec les API de gestion des clients du backend Go.
 class ClientService {
   // Remplacez cette URL par l'adresse IP de votre machine où le backend Go est exécuté.
   // Utilisez l'adresse IP de votre réseau local, pas localhost, pour que votre appareil/émulateur y accède.
   static const String _baseUrl = 'http://192.168.11.105:8080';
-  // Votre ADMIN_TOKEN défini dans le fichier .env de votre backend Go
-  static const String _adminToken = 'MonSuperTokenAdminPourRestoApp2025!'; // *** REMPLACEZ PAR VOTRE VRAI TOKEN ADMIN ***
-
   /// En-têtes spécifiques pour les requêtes administrateur
-  Map<String, String> _adminHeaders() {
+  /// (jeton d'accès de l'administrateur connecté, obtenu via /login)
+  Future<Map<String, String>> _adminHeaders() async {
     return {
       'Content-Type': 'application/json',
       'Accept': 'application/json',
-      'X-Admin-Token': _adminToken, // Le token secret est envoyé ici
+      ...await AuthService.authHeader(),
     };
   }
 
@@ -25,7 +24,7 @@ class ClientService {
   Future<List<Client>> fetchClients() async {
     final response = await http.get(
       Uri.parse('$_baseUrl/admin/clients'),
-      headers: _adminHeaders(), // Utilise les en-têtes avec le token admin
+      headers: await _adminHeaders(), // Utilise les en-têtes avec le token admin
     );
 
     print('DEBUG ClientService: FetchClients status: ${response.statusCode}');
@@ -36,7 +35,7 @@ class ClientService {
       // Correction ici: S'assurer que le .map renvoie une liste de Client
       return body.map<Client>((dynamic item) => Client.fromJson(item)).toList();
     } else if (response.statusCode == 403) {
-      throw Exception('Accès non autorisé. Vérifiez votre token admin.');
+      throw Exception('Accès non autorisé. Reconnectez-vous avec un compte administrateur.');
     } else {
       String errorMessage = 'Échec du chargement des clients.';
       try {
@@ -53,7 +52,7 @@ class ClientService {
   Future<Client> addClient(Client client) async {
     final response = await http.post(
       Uri.parse('$_baseUrl/admin/clients'),
-      headers: _adminHeaders(),
+      headers: await _adminHeaders(),
       body: json.encode(client.toJson()), // Utilise toJson() pour envoyer les données
     );
 
@@ -84,7 +83,7 @@ class ClientService {
     // CHANGEMENT ICI: Utilise client.id directement (qui est String?)
     final response = await http.put(
       Uri.parse('$_baseUrl/admin/clients/${client.id}'), // Utilise l'ID du client pour la mise à jour
-      headers: _adminHeaders(),
+      headers: await _adminHeaders(),
       body: json.encode(client.toJson()), // Utilise toJson() pour envoyer les données
     );
 
@@ -111,7 +110,7 @@ class ClientService {
   Future<void> deleteClient(String id) async {
     final response = await http.delete(
       Uri.parse('$_baseUrl/admin/clients/$id'),
-      headers: _adminHeaders(),
+      headers: await _adminHeaders(),
     );
 
     print('DEBUG ClientService: DeleteClient status: ${response.statusCode}');
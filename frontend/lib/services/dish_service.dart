@@ -4,18 +4,18 @@ import 'dart:convert';
 import 'dart:io'; // Pour le type File
 import 'package:http/http.dart' as http;
 import 'package:restaurant_app/models/plat.dart'; // Importez votre modèle Plat depuis son propre fichier
+import 'package:restaurant_app/services/auth_service.dart';
 
 /// Service pour interagir avec les API de gestion des plats du backend Go.
 class DishService {
   static const String _baseUrl = 'http://192.168.11.105:8080';
-  static const String _adminToken = 'MonSuperTokenAdminPourRestoApp2025!'; // *** REMPLACEZ PAR VOTRE VRAI TOKEN ADMIN ***
 
-  /// En-têtes pour les requêtes JSON simples (GET/DELETE)
-  Map<String, String> _jsonHeaders() {
+  /// En-têtes pour les requêtes JSON simples (GET/DELETE), avec le jeton d'accès de la session
+  Future<Map<String, String>> _jsonHeaders() async {
     return {
       'Content-Type': 'application/json',
       'Accept': 'application/json',
-      'X-Admin-Token': _adminToken,
+      ...await AuthService.authHeader(),
     };
   }
 
@@ -23,14 +23,14 @@ class DishService {
   Future<List<Plat>> fetchDishes() async {
     final response = await http.get(
       Uri.parse('$_baseUrl/dishes'), // <<<--- C'EST CETTE LIGNE QUI DOIT ÊTRE '/dishes' pour la récupération de TOUS les plats
-      headers: _jsonHeaders(),
+      headers: await _jsonHeaders(),
     );
 
     if (response.statusCode == 200) {
       List<dynamic> body = json.decode(response.body);
       return body.map<Plat>((dynamic item) => Plat.fromJson(item)).toList();
     } else if (response.statusCode == 403) {
-      throw Exception('Accès non autorisé. Vérifiez votre token admin.');
+      throw Exception('Accès non autorisé. Reconnectez-vous avec un compte administrateur.');
     } else {
       String errorMessage = 'Échec du chargement des plats.';
       try {
@@ -49,7 +49,7 @@ class DishService {
     final uri = Uri.parse('$_baseUrl/admin/dishes'); // Correct pour l'ajout
     var request = http.MultipartRequest('POST', uri);
     request.headers.addAll({
-      'X-Admin-Token': _adminToken,
+      ...await AuthService.authHeader(),
       'Accept': 'application/json',
     });
 
@@ -95,7 +95,7 @@ class DishService {
     final uri = Uri.parse('$_baseUrl/admin/dishes/${plat.idPlat}');
     var request = http.MultipartRequest('PUT', uri);
     request.headers.addAll({
-      'X-Admin-Token': _adminToken,
+      ...await AuthService.authHeader(),
       'Accept': 'application/json',
     });
 
@@ -140,7 +140,7 @@ class DishService {
     // <<<--- C'EST CETTE LIGNE QUI DOIT ÊTRE '/admin/dishes/$idPlat' pour la suppression par ID
     final response = await http.delete(
       Uri.parse('$_baseUrl/admin/dishes/$idPlat'),
-      headers: _jsonHeaders(),
+      headers: await _jsonHeaders(),
     );
 
     if (response.statusCode != 204) { // 204 No Content pour une suppression réussie